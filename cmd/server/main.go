@@ -13,8 +13,11 @@ import (
 	"kubezen/internal/api"
 	"kubezen/internal/auth"
 	"kubezen/internal/config"
+	"kubezen/internal/history"
 	"kubezen/internal/k8s"
+	"kubezen/internal/prometheus"
 	"kubezen/internal/store"
+	"kubezen/internal/telemetry"
 )
 
 func main() {
@@ -36,7 +39,26 @@ func main() {
 	defer userStore.Close()
 	logger.Info("user store initialized")
 
+	if cfg.Bootstrap.Enabled() {
+		seeds, err := store.BootstrapUsers(cfg.Bootstrap)
+		if err != nil {
+			logger.Error("failed to load bootstrap users", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		result, err := userStore.Seed(seeds, cfg.Bootstrap.Reconcile)
+		if err != nil {
+			logger.Error("failed to bootstrap users", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		logger.Info("users bootstrapped",
+			slog.Int("created", len(result.Created)),
+			slog.Int("updated", len(result.Updated)),
+			slog.Int("deleted", len(result.Deleted)),
+		)
+	}
+
 	authManager := auth.NewManager(cfg.Auth)
+	go authManager.RunJanitor(ctx, cfg.Auth.SessionCleanupInterval)
 	var oidcClient *auth.OIDCClient
 	if cfg.Auth.OIDCIssuerURL != "" {
 		client, err := auth.NewOIDCClient(ctx, cfg.Auth)
@@ -47,21 +69,63 @@ func main() {
 		}
 	}
 
-	cluster, err := k8s.NewCluster(cfg.Kube)
+	var ldapClient *auth.LDAPClient
+	if cfg.Auth.LDAPURL != "" {
+		client, err := auth.NewLDAPClient(cfg.Auth)
+		if err != nil {
+			logger.Warn("ldap setup failed, continuing without LDAP", slog.String("error", err.Error()))
+		} else {
+			ldapClient = client
+		}
+	}
+
+	registry, err := k8s.NewRegistry(ctx, cfg.Kube)
 	if err != nil {
 		logger.Error("failed to initialize kubernetes client", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	if cfg.Store.EncryptionKey == "" {
+		logger.Warn("KZ_STORE_ENCRYPTION_KEY not set, clusters can't be registered through the API")
+	} else if err := userStore.SetEncryptionKey(cfg.Store.EncryptionKey); err != nil {
+		logger.Error("failed to set up cluster credential encryption", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	storedClusters, err := userStore.ListClusters()
+	if err != nil {
+		logger.Error("failed to load registered clusters", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	for _, record := range storedClusters {
+		err := record.KubeconfigErr
+		if err == nil {
+			err = registry.Add(record.Name, []byte(record.Kubeconfig), record.Context)
+		}
+		if err != nil {
+			logger.Warn("skipping registered cluster", slog.String("cluster", record.Name), slog.String("error", err.Error()))
+			if err := registry.AddUnavailable(record.Name, err); err != nil {
+				logger.Warn("cannot list unavailable cluster", slog.String("cluster", record.Name), slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	// Sync in the background so login and the UI are up while the cache
+	// warms; resource endpoints answer 503 until then.
+	go registry.Warm(ctx, registry.DefaultName(), logger)
+	go registry.RunWatchdog(ctx, cfg.Kube.StaleThreshold, logger)
 
-	logger.Info("starting informer cache...")
-	if err := cluster.Start(ctx); err != nil {
-		logger.Error("informer start failed", slog.String("error", err.Error()))
+	if cfg.Metrics.HistoryEnabled {
+		go history.NewCollector(registry, userStore, cfg.Metrics, logger).Run(ctx)
+	}
+
+	promTemplates, err := prometheus.LoadTemplates(cfg.Prometheus.TemplatesFile)
+	if err != nil {
+		logger.Error("failed to load prometheus templates", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	logger.Info("informer cache synced")
+	promSources := prometheus.NewSources(cfg.Prometheus)
 
-	service := k8s.NewService(cluster.Client, cluster.Factory)
-	router := api.NewRouter(cfg, service, userStore, authManager, oidcClient)
+	telemetry.RegisterState(registry, authManager)
+	router := api.NewRouter(cfg, registry, userStore, authManager, oidcClient, ldapClient, promSources, promTemplates)
 
 	server := &http.Server{
 		Addr:         cfg.Server.Address,
@@ -70,6 +134,26 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	if cfg.Server.MetricsAddress != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", telemetry.Handler(cfg.Server.MetricsToken))
+		metricsServer := &http.Server{
+			Addr:              cfg.Server.MetricsAddress,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		}
+		go func() {
+			<-ctx.Done()
+			_ = metricsServer.Close()
+		}()
+		go func() {
+			logger.Info("metrics listening", slog.String("addr", cfg.Server.MetricsAddress))
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("metrics server error", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
 	go func() {
 		<-ctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
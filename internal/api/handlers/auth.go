@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"kubezen/internal/auth"
+	"kubezen/internal/telemetry"
 )
 
 type kubeconfigRequest struct {
@@ -20,6 +22,7 @@ type kubeconfigRequest struct {
 type sessionResponse struct {
 	Subject    string `json:"subject"`
 	Source     string `json:"source"`
+	Role       string `json:"role,omitempty"`
 	Context    string `json:"context,omitempty"`
 	HasRefresh bool   `json:"hasRefresh"`
 	ExpiresAt  string `json:"expiresAt,omitempty"`
@@ -63,9 +66,11 @@ func OIDCCallback(manager *auth.Manager, client *auth.OIDCClient) gin.HandlerFun
 		codeVerifier := manager.GetCodeVerifier(state)
 		payload, err := client.Exchange(c.Request.Context(), code, codeVerifier)
 		if err != nil {
+			telemetry.RecordLogin("oidc", false)
 			respondError(c, http.StatusBadRequest, err)
 			return
 		}
+		telemetry.RecordLogin("oidc", true)
 		session := manager.NewSessionFromOIDC(auth.DisplayName(payload), payload)
 		manager.WriteSessionCookie(c, session.ID)
 		respondOK(c, toSessionResponse(session))
@@ -82,6 +87,7 @@ func KubeconfigLogin(manager *auth.Manager) gin.HandlerFunc {
 		req.Context = strings.TrimSpace(req.Context)
 		cfg, err := clientcmd.Load([]byte(req.Kubeconfig))
 		if err != nil {
+			telemetry.RecordLogin("kubeconfig", false)
 			respondError(c, http.StatusBadRequest, err)
 			return
 		}
@@ -90,9 +96,11 @@ func KubeconfigLogin(manager *auth.Manager) gin.HandlerFunc {
 			contextName = cfg.CurrentContext
 		}
 		if _, ok := cfg.Contexts[contextName]; !ok {
+			telemetry.RecordLogin("kubeconfig", false)
 			respondError(c, http.StatusBadRequest, ErrBadRequest)
 			return
 		}
+		telemetry.RecordLogin("kubeconfig", true)
 
 		subject := req.User
 		if subject == "" {
@@ -105,6 +113,40 @@ func KubeconfigLogin(manager *auth.Manager) gin.HandlerFunc {
 	}
 }
 
+// LDAPLogin authenticates against the configured directory and maps the
+// user's groups to a KubeZen role.
+func LDAPLogin(client *auth.LDAPClient, manager *auth.Manager, defaultContext string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if client == nil {
+			respondError(c, http.StatusServiceUnavailable, ErrServiceUnavailable)
+			return
+		}
+		var req loginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := client.Authenticate(req.Username, req.Password)
+		telemetry.RecordLogin("ldap", err == nil)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrLDAPInvalidCredentials):
+				respondError(c, http.StatusUnauthorized, err)
+			case errors.Is(err, auth.ErrLDAPNoRole):
+				respondError(c, http.StatusForbidden, err)
+			default:
+				respondError(c, http.StatusBadGateway, err)
+			}
+			return
+		}
+
+		session := manager.NewSessionFromLDAP(user, defaultContext)
+		manager.WriteSessionCookie(c, session.ID)
+		respondOK(c, toSessionResponse(session))
+	}
+}
+
 func SessionInfo(manager *auth.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session, ok := manager.SessionFromRequest(c)
@@ -131,6 +173,7 @@ func toSessionResponse(session auth.Session) sessionResponse {
 	resp := sessionResponse{
 		Subject:    session.Subject,
 		Source:     string(session.Source),
+		Role:       session.Role,
 		Context:    session.Context,
 		HasRefresh: session.RefreshToken != "",
 	}
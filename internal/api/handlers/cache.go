@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/k8s"
+)
+
+type cacheUsageResponse struct {
+	Items      []k8s.CacheUsage `json:"items"`
+	TotalBytes int64            `json:"totalBytes"`
+}
+
+// CacheUsage estimates the memory of every running informer cache (admin
+// only), to see which resource types are worth trimming.
+func CacheUsage(registry *k8s.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := cacheUsageResponse{Items: registry.CacheUsage()}
+		if resp.Items == nil {
+			resp.Items = []k8s.CacheUsage{}
+		}
+		for _, usage := range resp.Items {
+			resp.TotalBytes += usage.Bytes
+		}
+		respondOK(c, resp)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+	"kubezen/internal/store"
+)
+
+type registerClusterRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Kubeconfig string `json:"kubeconfig" binding:"required"`
+	Context    string `json:"context"`
+}
+
+// ClusterFromPath resolves requests to the cluster named by the :cluster
+// path parameter.
+func ClusterFromPath(registry *k8s.Registry, manager *auth.Manager) ServiceResolver {
+	return func(c *gin.Context) (*k8s.Service, error) {
+		name := c.Param("cluster")
+		if session, ok := auth.GetSession(c); ok && !manager.CanAccessCluster(session, name) {
+			return nil, ErrForbidden
+		}
+		return registry.ReadyService(name)
+	}
+}
+
+// ListClusters returns the registered clusters the session may access and
+// their cache state.
+func ListClusters(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Without a session (dev bypass) everything is accessible.
+		session, hasSession := auth.GetSession(c)
+		clusters := make([]k8s.ClusterInfo, 0)
+		for _, cluster := range registry.List() {
+			if hasSession && !manager.CanAccessCluster(session, cluster.Name) {
+				continue
+			}
+			clusters = append(clusters, cluster)
+		}
+		respondOK(c, k8s.ListResponse[k8s.ClusterInfo]{
+			Items: clusters,
+			Count: len(clusters),
+		})
+	}
+}
+
+// RegisterCluster adds a cluster from an uploaded kubeconfig and persists it
+// (admin only). Informers start on the first request to the cluster.
+func RegisterCluster(registry *k8s.Registry, userStore *store.Store, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req registerClusterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+			respondError(c, http.StatusBadRequest, errors.New("invalid cluster name: "+strings.Join(errs, ", ")))
+			return
+		}
+		if !userStore.CanStoreClusters() {
+			respondError(c, http.StatusServiceUnavailable, fmt.Errorf("%w: set KZ_STORE_ENCRYPTION_KEY", store.ErrNoEncryptionKey))
+			return
+		}
+		if registry.Has(req.Name) {
+			respondError(c, http.StatusConflict, k8s.ErrClusterExists)
+			return
+		}
+
+		kubeconfig, err := clientcmd.Load([]byte(req.Kubeconfig))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		contextName := strings.TrimSpace(req.Context)
+		if contextName == "" {
+			contextName = kubeconfig.CurrentContext
+		}
+		if _, ok := kubeconfig.Contexts[contextName]; !ok {
+			respondError(c, http.StatusBadRequest, errors.New("context not found in kubeconfig"))
+			return
+		}
+
+		// Only the selected context with inline credentials is kept and
+		// stored; exec plugins and file references are rejected.
+		sanitized, err := k8s.SanitizeKubeconfig([]byte(req.Kubeconfig), contextName)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err := registry.Add(req.Name, sanitized, contextName); err != nil {
+			if errors.Is(err, k8s.ErrClusterExists) {
+				respondError(c, http.StatusConflict, err)
+				return
+			}
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		createdBy := ""
+		if session, ok := currentSession(c, manager); ok {
+			createdBy = session.Subject
+		}
+		if _, err := userStore.CreateCluster(req.Name, string(sanitized), contextName, createdBy); err != nil {
+			_ = registry.Remove(req.Name)
+			if errors.Is(err, store.ErrClusterAlreadyExists) {
+				respondError(c, http.StatusConflict, err)
+				return
+			}
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, info := range registry.List() {
+			if info.Name == req.Name {
+				c.JSON(http.StatusCreated, info)
+				return
+			}
+		}
+		c.Status(http.StatusCreated)
+	}
+}
+
+// UnregisterCluster removes a cluster that was added through the API (admin
+// only). Clusters from the kubeconfig can't be removed.
+func UnregisterCluster(registry *k8s.Registry, userStore *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("cluster")
+		if err := registry.Remove(name); err != nil {
+			if errors.Is(err, k8s.ErrClusterNotFound) {
+				respondError(c, http.StatusNotFound, err)
+				return
+			}
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err := userStore.DeleteCluster(name); err != nil && !errors.Is(err, store.ErrClusterNotFound) {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -1,19 +1,72 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
 )
 
 var (
 	ErrBadRequest         = errors.New("bad request")
 	ErrNotFound           = errors.New("not found")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
 	ErrServiceUnavailable = errors.New("service unavailable")
 )
 
+// cacheWarmingRetry is the Retry-After hint, in seconds, while a cluster's
+// cache syncs.
+const cacheWarmingRetry = "5"
+
+// ServiceResolver picks the cluster a request is served from.
+type ServiceResolver func(c *gin.Context) (*k8s.Service, error)
+
+// resolveService runs the resolver and writes the error response if the
+// cluster is unknown or not ready.
+func resolveService(c *gin.Context, resolve ServiceResolver) (*k8s.Service, bool) {
+	svc, err := resolve(c)
+	switch {
+	case err == nil:
+		return svc, true
+	case errors.Is(err, k8s.ErrClusterNotFound):
+		respondError(c, http.StatusNotFound, err)
+	case errors.Is(err, ErrForbidden):
+		respondError(c, http.StatusForbidden, err)
+	case errors.Is(err, k8s.ErrCacheWarming):
+		c.Header("Retry-After", cacheWarmingRetry)
+		respondError(c, http.StatusServiceUnavailable, err)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		respondError(c, http.StatusServiceUnavailable, ErrServiceUnavailable)
+	default:
+		respondError(c, http.StatusServiceUnavailable, err)
+	}
+	return nil, false
+}
+
+// respondServiceError writes an error from a k8s.Service call: 400 for an
+// invalid list query, 503 while the resource's informers sync, 403 for
+// resources that can't be cached and fallback otherwise.
+func respondServiceError(c *gin.Context, fallback int, err error) {
+	switch {
+	case errors.Is(err, k8s.ErrInvalidQuery):
+		respondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, k8s.ErrCacheWarming):
+		c.Header("Retry-After", cacheWarmingRetry)
+		respondError(c, http.StatusServiceUnavailable, err)
+	case errors.Is(err, k8s.ErrNotPermitted):
+		respondError(c, http.StatusForbidden, err)
+	default:
+		respondError(c, fallback, err)
+	}
+}
+
 func respondOK[T any](c *gin.Context, payload T) {
 	c.JSON(http.StatusOK, payload)
 }
@@ -24,6 +77,63 @@ func respondError(c *gin.Context, status int, err error) {
 	})
 }
 
+// listOptions reads the list query shared by every list endpoint: filters
+// (namespace, status, labels, fieldSelector, q), sort and order, and paging
+// (limit, continue, or the older offset).
+func listOptions(c *gin.Context) k8s.ListOptions {
+	limit, offset := parsePagination(c)
+	return k8s.ListOptions{
+		Namespace:     strings.TrimSpace(c.Query("namespace")),
+		Status:        strings.TrimSpace(c.Query("status")),
+		LabelSelector: strings.TrimSpace(c.Query("labels")),
+		FieldSelector: strings.TrimSpace(c.Query("fieldSelector")),
+		Query:         strings.TrimSpace(c.Query("q")),
+		Sort:          strings.TrimSpace(c.Query("sort")),
+		Order:         strings.TrimSpace(c.Query("order")),
+		Limit:         limit,
+		Offset:        offset,
+		Continue:      c.Query("continue"),
+	}
+}
+
+// respondList sorts and pages items per opts and writes them, reduced to the
+// ?fields= selection if given.
+func respondList[T any](c *gin.Context, items []T, opts k8s.ListOptions, cacheAge *int64) {
+	page, err := k8s.Page(items, opts)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	page.CacheAge = cacheAge
+	fields := queryFields(c)
+	if len(fields) == 0 {
+		respondOK(c, page)
+		return
+	}
+	projected, err := k8s.Project(page.Items, fields)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	respondOK(c, k8s.ListResponse[map[string]any]{
+		Items:    projected,
+		Count:    page.Count,
+		Continue: page.Continue,
+		CacheAge: page.CacheAge,
+	})
+}
+
+// queryFields splits the ?fields= projection, e.g. "name,usage.cpuMillicores".
+func queryFields(c *gin.Context) []string {
+	var fields []string
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 func parsePagination(c *gin.Context) (limit, offset int) {
 	limit = 0
 	offset = 0
@@ -39,3 +149,13 @@ func parsePagination(c *gin.Context) (limit, offset int) {
 	}
 	return
 }
+
+// isAdmin mirrors middleware.RequireAdmin for handlers that only tailor
+// their response to admins.
+func isAdmin(c *gin.Context, devBypass bool) bool {
+	if devBypass {
+		return true
+	}
+	session, ok := auth.GetSession(c)
+	return ok && auth.IsAdmin(session)
+}
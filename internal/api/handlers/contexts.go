@@ -2,13 +2,12 @@ package handlers
 
 import (
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
 )
 
 type ContextInfo struct {
@@ -16,6 +15,7 @@ type ContextInfo struct {
 	Cluster   string `json:"cluster"`
 	User      string `json:"user"`
 	Namespace string `json:"namespace,omitempty"`
+	Server    string `json:"server,omitempty"`
 	IsCurrent bool   `json:"isCurrent"`
 }
 
@@ -24,67 +24,130 @@ type ContextsResponse struct {
 	CurrentContext string        `json:"currentContext"`
 }
 
-// ListContexts returns all available contexts from kubeconfig
-func ListContexts(kubeconfigPath, currentContext string) gin.HandlerFunc {
+type switchContextRequest struct {
+	Context string `json:"context" binding:"required"`
+}
+
+// ListContexts returns the registered cluster contexts the caller may use.
+// The current context is the session's, falling back to the default cluster.
+func ListContexts(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		path := kubeconfigPath
-		if path == "" {
-			// Try default locations
-			if home := homedir.HomeDir(); home != "" {
-				path = filepath.Join(home, ".kube", "config")
-			}
-		}
-		// Expand ~ if present
-		if strings.HasPrefix(path, "~") {
-			if home := homedir.HomeDir(); home != "" {
-				path = strings.Replace(path, "~", home, 1)
-			}
+		respondOK(c, listContexts(c, registry, manager))
+	}
+}
+
+// SwitchContext points the caller's session at another cluster. Subsequent
+// requests without an explicit /clusters/:cluster prefix are served from it.
+func SwitchContext(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req switchContextRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
 		}
-		// Check KUBECONFIG env var
-		if envPath := os.Getenv("KUBECONFIG"); envPath != "" && path == "" {
-			path = envPath
+		req.Context = strings.TrimSpace(req.Context)
+
+		session, ok := currentSession(c, manager)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, ErrUnauthorized)
+			return
 		}
 
-		if path == "" {
-			respondError(c, http.StatusNotFound, ErrNotFound)
+		allowed := false
+		for _, ctx := range listContexts(c, registry, manager).Contexts {
+			if ctx.Name == req.Context {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			if registry.Has(req.Context) {
+				respondError(c, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			respondError(c, http.StatusNotFound, k8s.ErrClusterNotFound)
 			return
 		}
 
-		cfg, err := clientcmd.LoadFromFile(path)
-		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+		updated, ok := manager.SetSessionContext(session.ID, req.Context)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
+		auth.SetSession(c, updated)
+		respondOK(c, toSessionResponse(updated))
+	}
+}
 
-		// Determine which context is current (priority: env var > kubeconfig)
-		activeContext := currentContext
-		if activeContext == "" {
-			activeContext = cfg.CurrentContext
+// SessionCluster resolves requests to the cluster selected by the caller's
+// session, or the default cluster when none is selected.
+func SessionCluster(registry *k8s.Registry, manager *auth.Manager) ServiceResolver {
+	return func(c *gin.Context) (*k8s.Service, error) {
+		name := sessionClusterName(c, registry)
+		if session, ok := auth.GetSession(c); ok && !manager.CanAccessCluster(session, name) {
+			return nil, ErrForbidden
 		}
+		return registry.ReadyService(name)
+	}
+}
+
+// sessionClusterName is the cluster selected by the caller's session, or the
+// default cluster.
+func sessionClusterName(c *gin.Context, registry *k8s.Registry) string {
+	if session, ok := auth.GetSession(c); ok && session.Context != "" && registry.Has(session.Context) {
+		return session.Context
+	}
+	return registry.DefaultName()
+}
+
+// queryCluster resolves the ?cluster= parameter, defaulting to the session's
+// cluster, and writes the error response if it is unknown or not accessible.
+func queryCluster(c *gin.Context, registry *k8s.Registry, manager *auth.Manager) (string, bool) {
+	cluster := strings.TrimSpace(c.Query("cluster"))
+	if cluster == "" {
+		cluster = sessionClusterName(c, registry)
+	}
+	if !registry.Has(cluster) {
+		respondError(c, http.StatusNotFound, k8s.ErrClusterNotFound)
+		return "", false
+	}
+	if session, ok := auth.GetSession(c); ok && !manager.CanAccessCluster(session, cluster) {
+		respondError(c, http.StatusForbidden, ErrForbidden)
+		return "", false
+	}
+	return cluster, true
+}
+
+func listContexts(c *gin.Context, registry *k8s.Registry, manager *auth.Manager) ContextsResponse {
+	// Without a session (dev bypass) everything is accessible.
+	session, hasSession := auth.GetSession(c)
+	active := sessionClusterName(c, registry)
 
-		var contexts []ContextInfo
-		for name, ctx := range cfg.Contexts {
-			contexts = append(contexts, ContextInfo{
-				Name:      name,
-				Cluster:   ctx.Cluster,
-				User:      ctx.AuthInfo,
-				Namespace: ctx.Namespace,
-				IsCurrent: name == activeContext,
-			})
+	contexts := make([]ContextInfo, 0)
+	for _, cluster := range registry.List() {
+		if hasSession && !manager.CanAccessCluster(session, cluster.Name) {
+			continue
 		}
+		contexts = append(contexts, ContextInfo{
+			Name:      cluster.Name,
+			Cluster:   cluster.KubeCluster,
+			User:      cluster.User,
+			Namespace: cluster.Namespace,
+			Server:    cluster.Server,
+			IsCurrent: cluster.Name == active,
+		})
+	}
 
-		// Sort to put current context first
-		for i, ctx := range contexts {
-			if ctx.IsCurrent {
-				contexts[0], contexts[i] = contexts[i], contexts[0]
-				break
-			}
+	// Sort to put current context first
+	for i, ctx := range contexts {
+		if ctx.IsCurrent {
+			contexts[0], contexts[i] = contexts[i], contexts[0]
+			break
 		}
+	}
 
-		respondOK(c, ContextsResponse{
-			Contexts:       contexts,
-			CurrentContext: activeContext,
-		})
+	return ContextsResponse{
+		Contexts:       contexts,
+		CurrentContext: active,
 	}
 }
-
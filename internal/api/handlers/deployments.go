@@ -1,40 +1,146 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
-	"strings"
+	"reflect"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"kubezen/internal/k8s"
 )
 
-func ListDeployments(svc *k8s.Service) gin.HandlerFunc {
+func ListDeployments(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		namespace := strings.TrimSpace(c.Query("namespace"))
-
-		deployments, err := svc.ListDeployments(c.Request.Context(), namespace)
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := listOptions(c)
+		deployments, err := svc.ListDeployments(c.Request.Context(), opts)
 		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+			respondServiceError(c, http.StatusInternalServerError, err)
 			return
 		}
-
-		respondOK(c, k8s.ListResponse[k8s.DeploymentSummary]{
-			Items: deployments,
-			Count: len(deployments),
-		})
+		respondList(c, deployments, opts, svc.CacheAge("deployments"))
 	}
 }
 
-func GetDeployment(svc *k8s.Service) gin.HandlerFunc {
+func GetDeployment(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
 		namespace := c.Param("namespace")
 		name := c.Param("name")
 		deploy, err := svc.GetDeployment(c.Request.Context(), namespace, name)
 		if err != nil {
-			respondError(c, http.StatusNotFound, err)
+			respondServiceError(c, http.StatusNotFound, err)
 			return
 		}
 		respondOK(c, deploy)
 	}
 }
+
+// rolloutRecheck re-evaluates a rollout even without watch events, since
+// ReplicaSet changes aren't part of the watch stream.
+const rolloutRecheck = 2 * time.Second
+
+// RolloutStatus streams a Deployment's rollout progress as Server-Sent
+// Events, like `kubectl rollout status`. A "progress" event is sent whenever
+// the status changes, followed by a final "complete" or "failed" event.
+// ?timeout=5m ends the stream with a "timeout" event.
+func RolloutStatus(resolve ServiceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		namespace := c.Param("namespace")
+		name := c.Param("name")
+
+		ctx := c.Request.Context()
+		if v := c.Query("timeout"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil || timeout <= 0 {
+				respondError(c, http.StatusBadRequest, errors.New("invalid timeout"))
+				return
+			}
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		status, err := svc.RolloutStatus(ctx, namespace, name)
+		if err != nil {
+			respondServiceError(c, http.StatusNotFound, err)
+			return
+		}
+		sub, err := svc.Watch(ctx, k8s.WatchOptions{
+			Kinds:     []string{"Deployment", "Pod"},
+			Namespace: namespace,
+		})
+		if err != nil {
+			respondServiceError(c, http.StatusInternalServerError, err)
+			return
+		}
+		defer sub.Stop()
+
+		streamSSE(c)
+		c.SSEvent("progress", status)
+		if status.Done() {
+			c.SSEvent(status.Result, status)
+			return
+		}
+
+		events, dropped := sub.Events(), sub.Done()
+		recheck := time.NewTicker(rolloutRecheck)
+		defer recheck.Stop()
+		heartbeat := time.NewTicker(watchHeartbeat)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-events:
+			case <-recheck.C:
+			case <-heartbeat.C:
+				_, err := io.WriteString(w, ": heartbeat\n\n")
+				return err == nil
+			case <-dropped:
+				if errors.Is(sub.Err(), k8s.ErrCacheRestarted) {
+					// svc's cache is gone; the client reconnects to the new one.
+					c.SSEvent("error", gin.H{"error": sub.Err().Error()})
+					return false
+				}
+				// Dropped for falling behind; the periodic recheck still
+				// tracks the rollout.
+				events, dropped = nil, nil
+				return true
+			case <-ctx.Done():
+				if c.Request.Context().Err() == nil {
+					c.SSEvent("timeout", gin.H{"error": "timed out waiting for rollout"})
+				}
+				return false
+			}
+
+			next, err := svc.RolloutStatus(c.Request.Context(), namespace, name)
+			if err != nil {
+				c.SSEvent("error", gin.H{"error": err.Error()})
+				return false
+			}
+			if !reflect.DeepEqual(next, status) {
+				status = next
+				c.SSEvent("progress", status)
+			}
+			if status.Done() {
+				c.SSEvent(status.Result, status)
+				return false
+			}
+			return true
+		})
+	}
+}
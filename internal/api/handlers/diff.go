@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+)
+
+// DiffWorkloads compares workloads between two scopes, e.g.
+// ?source=staging/app&target=prod/app. A scope is "cluster/namespace", or
+// just "namespace" for the caller's current cluster. ?kinds= narrows the
+// comparison to deployments, statefulsets and/or configmaps.
+func DiffWorkloads(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kinds, err := k8s.ParseDiffKinds(c.Query("kinds"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		source, err := parseDiffScope(c, registry, c.Query("source"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		target, err := parseDiffScope(c, registry, c.Query("target"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		snapshots := make([]*k8s.WorkloadSnapshot, 0, 2)
+		for _, scope := range []k8s.DiffScope{source, target} {
+			svc, ok := resolveService(c, func(c *gin.Context) (*k8s.Service, error) {
+				if session, ok := auth.GetSession(c); ok && !manager.CanAccessCluster(session, scope.Cluster) {
+					return nil, ErrForbidden
+				}
+				return registry.ReadyService(scope.Cluster)
+			})
+			if !ok {
+				return
+			}
+			snapshot, err := svc.WorkloadSnapshot(c.Request.Context(), scope.Namespace, kinds)
+			if err != nil {
+				respondServiceError(c, http.StatusBadGateway, err)
+				return
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+
+		items := k8s.DiffWorkloads(snapshots[0], snapshots[1])
+		respondOK(c, k8s.DiffReport{
+			Source: source,
+			Target: target,
+			Kinds:  kinds,
+			Items:  items,
+			Count:  len(items),
+		})
+	}
+}
+
+func parseDiffScope(c *gin.Context, registry *k8s.Registry, raw string) (k8s.DiffScope, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return k8s.DiffScope{}, errors.New("source and target are required")
+	}
+	cluster, namespace, found := strings.Cut(raw, "/")
+	if !found {
+		namespace = cluster
+		cluster = sessionClusterName(c, registry)
+	}
+	if cluster == "" || namespace == "" {
+		return k8s.DiffScope{}, errors.New("invalid scope " + raw)
+	}
+	return k8s.DiffScope{Cluster: cluster, Namespace: namespace}, nil
+}
@@ -2,24 +2,31 @@ package handlers
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"kubezen/internal/k8s"
 )
 
-func ListEvents(svc *k8s.Service) gin.HandlerFunc {
+func ListEvents(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		namespace := strings.TrimSpace(c.Query("namespace"))
-		events, err := svc.ListEvents(c.Request.Context(), namespace)
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := listOptions(c)
+		if opts.Sort == "" {
+			// Newest first unless asked otherwise.
+			opts.Sort = "lastTimestamp"
+			if opts.Order == "" {
+				opts.Order = k8s.OrderDesc
+			}
+		}
+		events, err := svc.ListEvents(c.Request.Context(), opts)
 		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+			respondServiceError(c, http.StatusInternalServerError, err)
 			return
 		}
-		respondOK(c, k8s.ListResponse[k8s.EventSummary]{
-			Items: events,
-			Count: len(events),
-		})
+		respondList(c, events, opts, svc.CacheAge("events"))
 	}
 }
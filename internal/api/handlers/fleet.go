@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+)
+
+// FleetPods lists pods across every cluster the caller may access, e.g.
+// ?status=failed for every failed pod in the fleet. ?clusters=a,b narrows
+// the set. Unreachable clusters are reported under "errors". The list query
+// is the one of the single-cluster lists.
+func FleetPods(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusters, ok := fleetClusters(c, registry, manager)
+		if !ok {
+			return
+		}
+		resp, err := registry.FleetPods(c.Request.Context(), clusters, listOptions(c))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		respondFleet(c, resp)
+	}
+}
+
+// FleetNodes lists nodes across every cluster the caller may access.
+func FleetNodes(registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusters, ok := fleetClusters(c, registry, manager)
+		if !ok {
+			return
+		}
+		resp, err := registry.FleetNodes(c.Request.Context(), clusters, listOptions(c))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		respondFleet(c, resp)
+	}
+}
+
+// respondFleet writes a fleet view, reduced to the ?fields= selection if
+// given.
+func respondFleet[T any](c *gin.Context, resp k8s.FleetResponse[T]) {
+	fields := queryFields(c)
+	if len(fields) == 0 {
+		respondOK(c, resp)
+		return
+	}
+	projected, err := k8s.Project(resp.Items, fields)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	respondOK(c, k8s.FleetResponse[map[string]any]{
+		Items:    projected,
+		Count:    resp.Count,
+		Continue: resp.Continue,
+		Errors:   resp.Errors,
+	})
+}
+
+// fleetClusters returns the clusters a fleet view should cover: the
+// ?clusters= selection if given, otherwise all registered clusters, limited
+// to those the caller may access.
+func fleetClusters(c *gin.Context, registry *k8s.Registry, manager *auth.Manager) ([]string, bool) {
+	session, hasSession := auth.GetSession(c)
+
+	var requested []string
+	if v := strings.TrimSpace(c.Query("clusters")); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				requested = append(requested, name)
+			}
+		}
+	}
+
+	if len(requested) > 0 {
+		for _, name := range requested {
+			if !registry.Has(name) {
+				respondError(c, http.StatusNotFound, k8s.ErrClusterNotFound)
+				return nil, false
+			}
+			if hasSession && !manager.CanAccessCluster(session, name) {
+				respondError(c, http.StatusForbidden, ErrForbidden)
+				return nil, false
+			}
+		}
+		return requested, true
+	}
+
+	clusters := make([]string, 0)
+	for _, info := range registry.List() {
+		if hasSession && !manager.CanAccessCluster(session, info.Name) {
+			continue
+		}
+		clusters = append(clusters, info.Name)
+	}
+	return clusters, true
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+	"kubezen/internal/store"
+)
+
+const healthTimeout = 5 * time.Second
+
+type healthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status   string              `json:"status"`
+	Failed   []string            `json:"failed,omitempty"`
+	Checks   []healthCheck       `json:"checks,omitempty"`
+	Clusters []k8s.ClusterHealth `json:"clusters,omitempty"`
+}
+
+// Healthz is the liveness check: the process serves requests and its
+// database answers. Cluster problems don't fail it, since a restart won't
+// fix them. Admins get the failed checks, and with ?verbose every check.
+func Healthz(userStore *store.Store, manager *auth.Manager, devBypass bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+		defer cancel()
+		respondHealth(c, manager, devBypass, []healthCheck{storeCheck(ctx, userStore)}, nil)
+	}
+}
+
+// Readyz is the readiness check. It fails when the database is unreachable,
+// when any started cluster has an informer that hasn't synced, or when the
+// default cluster's API server can't be reached. Other clusters being
+// unreachable is only reported, so one broken cluster doesn't take the whole
+// dashboard out of rotation. Details are for admins, see Healthz; ?verbose
+// adds per-cluster details.
+func Readyz(userStore *store.Store, registry *k8s.Registry, manager *auth.Manager, devBypass bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+		defer cancel()
+
+		checks := []healthCheck{storeCheck(ctx, userStore)}
+		clusters := registry.Health(ctx)
+		defaultStarted := false
+		for _, cluster := range clusters {
+			check := healthCheck{Name: "cluster/" + cluster.Cluster, OK: true}
+			if cluster.Default {
+				defaultStarted = true
+				check.OK = cluster.Healthy()
+				check.Error = cluster.Error
+			}
+			for _, informer := range cluster.Informers {
+				if !informer.Synced {
+					check.OK = false
+					check.Error = informer.Resource + " informer not synced"
+				}
+			}
+			if !check.OK && check.Error == "" {
+				check.Error = "informers not synced"
+			}
+			checks = append(checks, check)
+		}
+		if !defaultStarted {
+			checks = append(checks, healthCheck{
+				Name:  "cluster/" + registry.DefaultName(),
+				Error: "not started",
+			})
+		}
+		respondHealth(c, manager, devBypass, checks, clusters)
+	}
+}
+
+func storeCheck(ctx context.Context, userStore *store.Store) healthCheck {
+	check := healthCheck{Name: "store", OK: true}
+	if err := userStore.Ping(ctx); err != nil {
+		check.OK = false
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// respondHealth answers with the overall status. Check names and errors
+// reveal cluster names and API errors, so only admins get them; the probes
+// are unauthenticated.
+func respondHealth(c *gin.Context, manager *auth.Manager, devBypass bool, checks []healthCheck, clusters []k8s.ClusterHealth) {
+	resp := healthResponse{Status: "ok"}
+	status := http.StatusOK
+	var failed []string
+	for _, check := range checks {
+		if !check.OK {
+			failed = append(failed, check.Name)
+		}
+	}
+	if len(failed) > 0 {
+		resp.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+
+	session, ok := manager.SessionFromRequest(c)
+	if devBypass || ok && auth.IsAdmin(session) {
+		resp.Failed = failed
+		if c.Request.URL.Query().Has("verbose") {
+			resp.Checks = checks
+			resp.Clusters = clusters
+		}
+	}
+	c.JSON(status, resp)
+}
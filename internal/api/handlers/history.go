@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/config"
+	"kubezen/internal/k8s"
+	"kubezen/internal/store"
+)
+
+type metricsHistoryResponse struct {
+	Cluster   string               `json:"cluster"`
+	Kind      string               `json:"kind"`
+	Namespace string               `json:"namespace,omitempty"`
+	Name      string               `json:"name"`
+	Range     string               `json:"range"`
+	Points    []store.MetricSample `json:"points"`
+}
+
+// MetricsHistory returns recorded usage for one pod or node, e.g.
+// ?kind=pod&namespace=default&name=web&range=1h. ?cluster= defaults to the
+// caller's current cluster.
+func MetricsHistory(userStore *store.Store, registry *k8s.Registry, manager *auth.Manager, cfg config.MetricsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.HistoryEnabled {
+			respondError(c, http.StatusNotFound, errors.New("metrics history is disabled"))
+			return
+		}
+
+		kind := strings.ToLower(strings.TrimSpace(c.DefaultQuery("kind", "pod")))
+		namespace := strings.TrimSpace(c.Query("namespace"))
+		name := strings.TrimSpace(c.Query("name"))
+		switch {
+		case kind != "pod" && kind != "node":
+			respondError(c, http.StatusBadRequest, errors.New("kind must be pod or node"))
+			return
+		case name == "":
+			respondError(c, http.StatusBadRequest, errors.New("name required"))
+			return
+		case kind == "pod" && namespace == "":
+			respondError(c, http.StatusBadRequest, errors.New("namespace required"))
+			return
+		case kind == "node":
+			namespace = ""
+		}
+
+		window, err := time.ParseDuration(c.DefaultQuery("range", "1h"))
+		if err != nil || window <= 0 {
+			respondError(c, http.StatusBadRequest, errors.New("invalid range"))
+			return
+		}
+		if cfg.HistoryRetention > 0 && window > cfg.HistoryRetention {
+			window = cfg.HistoryRetention
+		}
+
+		cluster, ok := queryCluster(c, registry, manager)
+		if !ok {
+			return
+		}
+
+		points, err := userStore.MetricHistory(cluster, kind, namespace, name, time.Now().Add(-window))
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		respondOK(c, metricsHistoryResponse{
+			Cluster:   cluster,
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Range:     window.String(),
+			Points:    points,
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/store"
+)
+
+type createInviteRequest struct {
+	Username  string `json:"username" binding:"omitempty,min=3,max=50"`
+	Email     string `json:"email" binding:"omitempty,email"`
+	Role      string `json:"role" binding:"omitempty,oneof=admin user"`
+	ExpiresIn string `json:"expiresIn"` // Go duration, e.g. "48h"
+}
+
+type createInviteResponse struct {
+	Invite *store.Invite `json:"invite"`
+	Token  string        `json:"token"`
+	URL    string        `json:"url"`
+}
+
+type redeemInviteRequest struct {
+	Username string `json:"username" binding:"omitempty,min=3,max=50"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// CreateInvite issues a one-time onboarding link (admin only). The token is
+// only returned here; the store keeps a hash.
+func CreateInvite(userStore *store.Store, manager *auth.Manager, publicURL string, defaultTTL time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createInviteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		if req.Username == "" && req.Email == "" {
+			respondError(c, http.StatusBadRequest, errors.New("username or email required"))
+			return
+		}
+		if req.Role == "" {
+			req.Role = "user"
+		}
+		ttl := defaultTTL
+		if req.ExpiresIn != "" {
+			parsed, err := time.ParseDuration(req.ExpiresIn)
+			if err != nil || parsed <= 0 {
+				respondError(c, http.StatusBadRequest, errors.New("invalid expiresIn"))
+				return
+			}
+			ttl = parsed
+		}
+		if req.Username != "" {
+			if _, err := userStore.GetUserByUsername(req.Username); err == nil {
+				respondError(c, http.StatusConflict, store.ErrUserAlreadyExists)
+				return
+			}
+		}
+
+		createdBy := ""
+		if session, ok := currentSession(c, manager); ok {
+			createdBy = session.Subject
+		}
+		invite, token, err := userStore.CreateInvite(req.Username, req.Email, req.Role, createdBy, ttl)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, createInviteResponse{
+			Invite: invite,
+			Token:  token,
+			URL:    inviteURL(c, publicURL, token),
+		})
+	}
+}
+
+// ListInvites returns all invites (admin only).
+func ListInvites(userStore *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invites, err := userStore.ListInvites()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if invites == nil {
+			invites = []store.Invite{}
+		}
+		respondOK(c, gin.H{"items": invites, "count": len(invites)})
+	}
+}
+
+// RevokeInvite deletes an invite (admin only).
+func RevokeInvite(userStore *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		if err := userStore.DeleteInvite(id); err != nil {
+			respondInviteError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetInvite lets the invitee's browser show who the invite is for before
+// choosing a password.
+func GetInvite(userStore *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invite, err := userStore.GetInviteByToken(c.Param("token"))
+		if err != nil {
+			respondInviteError(c, err)
+			return
+		}
+		respondOK(c, gin.H{
+			"username":  invite.Username,
+			"email":     invite.Email,
+			"role":      invite.Role,
+			"expiresAt": invite.ExpiresAt,
+		})
+	}
+}
+
+// RedeemInvite creates the invited user with their chosen password and logs
+// them in.
+func RedeemInvite(userStore *store.Store, manager *auth.Manager, defaultContext string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req redeemInviteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		token := c.Param("token")
+		invite, err := userStore.GetInviteByToken(token)
+		if err != nil {
+			respondInviteError(c, err)
+			return
+		}
+		if invite.Username == "" && req.Username == "" {
+			respondError(c, http.StatusBadRequest, errors.New("username required"))
+			return
+		}
+
+		user, err := userStore.RedeemInvite(token, req.Username, req.Password)
+		if err != nil {
+			respondInviteError(c, err)
+			return
+		}
+
+		session := manager.NewSessionFromLocal(user.Username, user.Role, defaultContext)
+		manager.WriteSessionCookie(c, session.ID)
+		respondOK(c, toSessionResponse(session))
+	}
+}
+
+func inviteURL(c *gin.Context, publicURL, token string) string {
+	base := publicURL
+	if base == "" {
+		// Fall back to the UI origin that made the request.
+		base = strings.TrimSuffix(c.GetHeader("Origin"), "/")
+	}
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/invite/" + url.PathEscape(token)
+}
+
+func respondInviteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrInviteNotFound):
+		respondError(c, http.StatusNotFound, err)
+	case errors.Is(err, store.ErrInviteInvalid):
+		respondError(c, http.StatusGone, err)
+	default:
+		respondUserError(c, err)
+	}
+}
@@ -4,22 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-
-	"kubezen/internal/k8s"
 )
 
-func ListNamespaces(svc *k8s.Service) gin.HandlerFunc {
+func ListNamespaces(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		namespaces, err := svc.ListNamespaces(c.Request.Context())
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := listOptions(c)
+		namespaces, err := svc.ListNamespaces(c.Request.Context(), opts)
 		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+			respondServiceError(c, http.StatusInternalServerError, err)
 			return
 		}
-
-		respondOK(c, k8s.ListResponse[k8s.NamespaceSummary]{
-			Items: namespaces,
-			Count: len(namespaces),
-		})
+		respondList(c, namespaces, opts, svc.CacheAge("namespaces"))
 	}
 }
 
@@ -27,8 +26,12 @@ type namespaceRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
-func CreateNamespace(svc *k8s.Service) gin.HandlerFunc {
+func CreateNamespace(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
 		var req namespaceRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			respondError(c, http.StatusBadRequest, err)
@@ -42,8 +45,12 @@ func CreateNamespace(svc *k8s.Service) gin.HandlerFunc {
 	}
 }
 
-func DeleteNamespace(svc *k8s.Service) gin.HandlerFunc {
+func DeleteNamespace(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
 		name := c.Param("name")
 		if name == "" {
 			respondError(c, http.StatusBadRequest, ErrBadRequest)
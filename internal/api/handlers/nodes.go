@@ -4,31 +4,34 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-
-	"kubezen/internal/k8s"
 )
 
-func ListNodes(svc *k8s.Service) gin.HandlerFunc {
+func ListNodes(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		nodes, err := svc.ListNodes(c.Request.Context())
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := listOptions(c)
+		nodes, err := svc.ListNodes(c.Request.Context(), opts)
 		if err != nil {
-			respondError(c, http.StatusInternalServerError, err)
+			respondServiceError(c, http.StatusInternalServerError, err)
 			return
 		}
-
-		respondOK(c, k8s.ListResponse[k8s.NodeSummary]{
-			Items: nodes,
-			Count: len(nodes),
-		})
+		respondList(c, nodes, opts, svc.CacheAge("nodes"))
 	}
 }
 
-func GetNode(svc *k8s.Service) gin.HandlerFunc {
+func GetNode(resolve ServiceResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
 		name := c.Param("name")
 		node, err := svc.GetNode(c.Request.Context(), name)
 		if err != nil {
-			respondError(c, http.StatusNotFound, err)
+			respondServiceError(c, http.StatusNotFound, err)
 			return
 		}
 		respondOK(c, node)
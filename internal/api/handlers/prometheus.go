@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+	"kubezen/internal/prometheus"
+)
+
+const (
+	maxChartRange = 7 * 24 * time.Hour
+	// Prometheus rejects range queries with more than 11000 points.
+	maxChartPoints = 11000
+)
+
+type chartResponse struct {
+	Name   string              `json:"name"`
+	Title  string              `json:"title"`
+	Unit   string              `json:"unit"`
+	Query  string              `json:"query,omitempty"` // admins only
+	Series []prometheus.Series `json:"series"`
+	Error  string              `json:"error,omitempty"`
+}
+
+type chartsResponse struct {
+	Cluster   string          `json:"cluster"`
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name"`
+	Start     time.Time       `json:"start"`
+	End       time.Time       `json:"end"`
+	Step      string          `json:"step"`
+	Charts    []chartResponse `json:"charts"`
+}
+
+type queryResponse struct {
+	Cluster string              `json:"cluster"`
+	Query   string              `json:"query"`
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	Step    string              `json:"step"`
+	Series  []prometheus.Series `json:"series"`
+}
+
+// PrometheusCharts runs the chart templates of a kind (pod, node, deployment)
+// for one object, e.g. /prometheus/charts/pod?namespace=x&name=y&range=1h.
+// A failing chart is reported in its error field. The rendered PromQL is
+// only included for admins.
+func PrometheusCharts(sources *prometheus.Sources, templates prometheus.Templates, registry *k8s.Registry, manager *auth.Manager, devBypass bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := strings.ToLower(c.Param("kind"))
+		list, ok := templates[kind]
+		if !ok {
+			respondError(c, http.StatusNotFound, errors.New("no chart templates for "+kind))
+			return
+		}
+		namespace := strings.TrimSpace(c.Query("namespace"))
+		name := strings.TrimSpace(c.Query("name"))
+		if name == "" || (kind != "node" && namespace == "") {
+			respondError(c, http.StatusBadRequest, errors.New("namespace and name required"))
+			return
+		}
+		if kind == "node" {
+			namespace = ""
+		}
+
+		cluster, client, ok := prometheusSource(c, sources, registry, manager)
+		if !ok {
+			return
+		}
+		start, end, step, ok := parseChartRange(c)
+		if !ok {
+			return
+		}
+
+		vars := prometheus.Vars{Namespace: namespace, Name: name, Window: prometheus.RateWindow(step)}
+		showQuery := isAdmin(c, devBypass)
+		charts := make([]chartResponse, len(list))
+		queries := make([]string, len(list))
+		for i, tpl := range list {
+			query, err := tpl.Render(vars)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, err)
+				return
+			}
+			queries[i] = query
+			charts[i] = chartResponse{Name: tpl.Name, Title: tpl.Title, Unit: tpl.Unit, Series: []prometheus.Series{}}
+			if showQuery {
+				charts[i].Query = query
+			}
+		}
+
+		var wg sync.WaitGroup
+		for i, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := client.QueryRange(c.Request.Context(), query, start, end, step)
+				if err != nil {
+					charts[i].Error = err.Error()
+					return
+				}
+				charts[i].Series = series
+			}()
+		}
+		wg.Wait()
+
+		respondOK(c, chartsResponse{
+			Cluster:   cluster,
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Start:     start,
+			End:       end,
+			Step:      step.String(),
+			Charts:    charts,
+		})
+	}
+}
+
+// PrometheusQuery runs raw PromQL as a range query (admin only).
+func PrometheusQuery(sources *prometheus.Sources, registry *k8s.Registry, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := strings.TrimSpace(c.Query("query"))
+		if query == "" {
+			respondError(c, http.StatusBadRequest, errors.New("query required"))
+			return
+		}
+		cluster, client, ok := prometheusSource(c, sources, registry, manager)
+		if !ok {
+			return
+		}
+		start, end, step, ok := parseChartRange(c)
+		if !ok {
+			return
+		}
+		series, err := client.QueryRange(c.Request.Context(), query, start, end, step)
+		if err != nil {
+			respondError(c, http.StatusBadGateway, err)
+			return
+		}
+		respondOK(c, queryResponse{
+			Cluster: cluster,
+			Query:   query,
+			Start:   start,
+			End:     end,
+			Step:    step.String(),
+			Series:  series,
+		})
+	}
+}
+
+func prometheusSource(c *gin.Context, sources *prometheus.Sources, registry *k8s.Registry, manager *auth.Manager) (string, *prometheus.Client, bool) {
+	cluster, ok := queryCluster(c, registry, manager)
+	if !ok {
+		return "", nil, false
+	}
+	client, err := sources.For(cluster)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err)
+		return "", nil, false
+	}
+	return cluster, client, true
+}
+
+// parseChartRange reads ?range= (default 1h) and ?step= (default range/120,
+// at least 15s).
+func parseChartRange(c *gin.Context) (start, end time.Time, step time.Duration, ok bool) {
+	window, err := time.ParseDuration(c.DefaultQuery("range", "1h"))
+	if err != nil || window <= 0 || window > maxChartRange {
+		respondError(c, http.StatusBadRequest, errors.New("invalid range"))
+		return
+	}
+	step = window / 120
+	if v := c.Query("step"); v != "" {
+		step, err = time.ParseDuration(v)
+		if err != nil || step <= 0 {
+			respondError(c, http.StatusBadRequest, errors.New("invalid step"))
+			return
+		}
+	}
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	if int64(window/step) > maxChartPoints {
+		respondError(c, http.StatusBadRequest, errors.New("step too small for range"))
+		return
+	}
+	end = time.Now().UTC().Truncate(step)
+	return end.Add(-window), end, step, true
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+)
+
+type sessionListItem struct {
+	ID        string     `json:"id"`
+	Subject   string     `json:"subject"`
+	Source    string     `json:"source"`
+	Role      string     `json:"role,omitempty"`
+	Context   string     `json:"context,omitempty"`
+	ClientIP  string     `json:"clientIP,omitempty"`
+	UserAgent string     `json:"userAgent,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Current   bool       `json:"current"`
+}
+
+type sessionListResponse struct {
+	Items []sessionListItem `json:"items"`
+	Count int               `json:"count"`
+}
+
+// ListMySessions returns the sessions held by the calling user.
+func ListMySessions(manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := currentSession(c, manager)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		respondOK(c, toSessionList(manager.SessionsForSubject(current.Source, current.Subject), current.ID))
+	}
+}
+
+// RevokeMySession lets a user end one of their own sessions.
+func RevokeMySession(manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := currentSession(c, manager)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		publicID := c.Param("id")
+		for _, s := range manager.SessionsForSubject(current.Source, current.Subject) {
+			if s.PublicID == publicID {
+				manager.RevokeSession(publicID)
+				c.Status(http.StatusNoContent)
+				return
+			}
+		}
+		respondError(c, http.StatusNotFound, ErrNotFound)
+	}
+}
+
+// ListSessions returns every active session (admin only).
+func ListSessions(manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, _ := currentSession(c, manager)
+		respondOK(c, toSessionList(manager.ListSessions(), current.ID))
+	}
+}
+
+// RevokeSession ends any session by its public ID (admin only).
+func RevokeSession(manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := manager.RevokeSession(c.Param("id")); !ok {
+			respondError(c, http.StatusNotFound, ErrNotFound)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// currentSession prefers the session stored by the auth middleware and falls
+// back to the cookie when the dev bypass skipped it.
+func currentSession(c *gin.Context, manager *auth.Manager) (auth.Session, bool) {
+	if session, ok := auth.GetSession(c); ok {
+		return session, true
+	}
+	return manager.SessionFromRequest(c)
+}
+
+func toSessionList(sessions []auth.Session, currentID string) sessionListResponse {
+	items := make([]sessionListItem, 0, len(sessions))
+	for _, s := range sessions {
+		item := sessionListItem{
+			ID:        s.PublicID,
+			Subject:   s.Subject,
+			Source:    string(s.Source),
+			Role:      s.Role,
+			Context:   s.Context,
+			ClientIP:  s.ClientIP,
+			UserAgent: s.UserAgent,
+			CreatedAt: s.CreatedAt,
+			Current:   currentID != "" && s.ID == currentID,
+		}
+		if !s.LastSeen.IsZero() {
+			lastSeen := s.LastSeen
+			item.LastSeen = &lastSeen
+		}
+		if !s.ExpiresAt.IsZero() {
+			expiresAt := s.ExpiresAt
+			item.ExpiresAt = &expiresAt
+		}
+		items = append(items, item)
+	}
+	return sessionListResponse{Items: items, Count: len(items)}
+}
@@ -7,6 +7,7 @@ import (
 
 	"kubezen/internal/auth"
 	"kubezen/internal/store"
+	"kubezen/internal/telemetry"
 )
 
 type authStatusResponse struct {
@@ -25,7 +26,8 @@ type loginRequest struct {
 }
 
 // AuthStatus returns whether initial setup is needed and available auth methods.
-func AuthStatus(userStore *store.Store, oidcEnabled bool) gin.HandlerFunc {
+// Setup is never reported as needed when users are bootstrapped from config.
+func AuthStatus(userStore *store.Store, setupEnabled, oidcEnabled, ldapEnabled bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		count, err := userStore.CountUsers()
 		if err != nil {
@@ -37,9 +39,12 @@ func AuthStatus(userStore *store.Store, oidcEnabled bool) gin.HandlerFunc {
 		if oidcEnabled {
 			methods = append(methods, "oidc")
 		}
+		if ldapEnabled {
+			methods = append(methods, "ldap")
+		}
 
 		respondOK(c, authStatusResponse{
-			NeedsSetup:  count == 0,
+			NeedsSetup:  setupEnabled && count == 0,
 			AuthMethods: methods,
 		})
 	}
@@ -80,12 +85,7 @@ func InitialSetup(userStore *store.Store, manager *auth.Manager, defaultContext
 		session := manager.NewSessionFromLocal(user.Username, user.Role, defaultContext)
 		manager.WriteSessionCookie(c, session.ID)
 
-		respondOK(c, sessionResponse{
-			Subject:    session.Subject,
-			Source:     string(session.Source),
-			Context:    session.Context,
-			HasRefresh: false,
-		})
+		respondOK(c, toSessionResponse(session))
 	}
 }
 
@@ -102,6 +102,7 @@ func LocalLogin(userStore *store.Store, manager *auth.Manager, defaultContext st
 		user, err := userStore.GetUserByUsername(req.Username)
 		if err != nil {
 			if err == store.ErrUserNotFound {
+				telemetry.RecordLogin("local", false)
 				respondError(c, http.StatusUnauthorized, store.ErrInvalidPassword)
 				return
 			}
@@ -111,19 +112,17 @@ func LocalLogin(userStore *store.Store, manager *auth.Manager, defaultContext st
 
 		// Verify password
 		if !userStore.VerifyPassword(user, req.Password) {
+			telemetry.RecordLogin("local", false)
 			respondError(c, http.StatusUnauthorized, store.ErrInvalidPassword)
 			return
 		}
 
+		telemetry.RecordLogin("local", true)
+
 		// Create session
 		session := manager.NewSessionFromLocal(user.Username, user.Role, defaultContext)
 		manager.WriteSessionCookie(c, session.ID)
 
-		respondOK(c, sessionResponse{
-			Subject:    session.Subject,
-			Source:     string(session.Source),
-			Context:    session.Context,
-			HasRefresh: false,
-		})
+		respondOK(c, toSessionResponse(session))
 	}
 }
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/k8s"
+)
+
+// TopPods lists pods by current usage, like `kubectl top pods`. Takes the
+// list query of every list; ?sort=cpu (default) or memory orders by usage.
+func TopPods(resolve ServiceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := k8s.UsageListOptions(listOptions(c))
+		pods, err := svc.TopPods(c.Request.Context(), opts)
+		if err != nil {
+			respondMetricsError(c, err)
+			return
+		}
+		respondList(c, pods, opts, nil)
+	}
+}
+
+// TopNodes lists nodes by current usage, like `kubectl top nodes`, see
+// TopPods.
+func TopNodes(resolve ServiceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		opts := k8s.UsageListOptions(listOptions(c))
+		nodes, err := svc.TopNodes(c.Request.Context(), opts)
+		if err != nil {
+			respondMetricsError(c, err)
+			return
+		}
+		respondList(c, nodes, opts, nil)
+	}
+}
+
+func respondMetricsError(c *gin.Context, err error) {
+	if errors.Is(err, k8s.ErrMetricsUnavailable) {
+		respondError(c, http.StatusServiceUnavailable, err)
+		return
+	}
+	respondServiceError(c, http.StatusInternalServerError, err)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/store"
+)
+
+type setPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
+// ListUsers returns all local users (admin only).
+func ListUsers(userStore *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		users, err := userStore.ListUsers()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if users == nil {
+			users = []store.User{}
+		}
+		respondOK(c, gin.H{"items": users, "count": len(users)})
+	}
+}
+
+// DeleteUser removes a local user and revokes their sessions (admin only).
+func DeleteUser(userStore *store.Store, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := userFromParam(c, userStore)
+		if !ok {
+			return
+		}
+		if err := userStore.DeleteUser(user.ID); err != nil {
+			respondUserError(c, err)
+			return
+		}
+		manager.RevokeSubjectSessions(auth.SourceLocal, user.Username, "")
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// SetUserPassword resets another user's password and revokes their sessions
+// (admin only).
+func SetUserPassword(userStore *store.Store, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := userFromParam(c, userStore)
+		if !ok {
+			return
+		}
+		var req setPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err := userStore.UpdatePassword(user.ID, req.Password); err != nil {
+			respondUserError(c, err)
+			return
+		}
+		manager.RevokeSubjectSessions(auth.SourceLocal, user.Username, "")
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ChangeMyPassword lets a local user change their own password. All of the
+// user's other sessions are revoked; the calling session stays valid.
+func ChangeMyPassword(userStore *store.Store, manager *auth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := currentSession(c, manager)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		if session.Source != auth.SourceLocal {
+			respondError(c, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		var req changePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+		user, err := userStore.GetUserByUsername(session.Subject)
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+		if !userStore.VerifyPassword(user, req.CurrentPassword) {
+			respondError(c, http.StatusUnauthorized, store.ErrInvalidPassword)
+			return
+		}
+		if err := userStore.UpdatePassword(user.ID, req.NewPassword); err != nil {
+			respondUserError(c, err)
+			return
+		}
+		manager.RevokeSubjectSessions(auth.SourceLocal, user.Username, session.ID)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func userFromParam(c *gin.Context, userStore *store.Store) (*store.User, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrBadRequest)
+		return nil, false
+	}
+	user, err := userStore.GetUserByID(id)
+	if err != nil {
+		respondUserError(c, err)
+		return nil, false
+	}
+	return user, true
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrUserNotFound):
+		respondError(c, http.StatusNotFound, err)
+	case errors.Is(err, store.ErrUserAlreadyExists):
+		respondError(c, http.StatusConflict, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/k8s"
+)
+
+const watchHeartbeat = 15 * time.Second
+
+// Watch streams resource changes as Server-Sent Events, e.g.
+// ?kinds=pods,deployments&namespace=x. Each event is named after its type
+// (ADDED, MODIFIED, DELETED) and carries a k8s.WatchEvent. ?initial=true
+// replays the current objects first. A heartbeat comment is sent every 15s;
+// a client that falls behind receives an "error" event and is disconnected.
+func Watch(resolve ServiceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc, ok := resolveService(c, resolve)
+		if !ok {
+			return
+		}
+		kinds, err := k8s.ParseWatchKinds(c.Query("kinds"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		sub, err := svc.Watch(c.Request.Context(), k8s.WatchOptions{
+			Kinds:     kinds,
+			Namespace: strings.TrimSpace(c.Query("namespace")),
+			Initial:   c.Query("initial") == "true",
+		})
+		if err != nil {
+			respondServiceError(c, http.StatusBadRequest, err)
+			return
+		}
+		defer sub.Stop()
+
+		streamSSE(c)
+		heartbeat := time.NewTicker(watchHeartbeat)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case ev := <-sub.Events():
+				c.SSEvent(ev.Type, ev)
+				return true
+			case <-heartbeat.C:
+				_, err := io.WriteString(w, ": heartbeat\n\n")
+				return err == nil
+			case <-sub.Done():
+				if err := sub.Err(); err != nil {
+					c.SSEvent("error", gin.H{"error": err.Error()})
+				}
+				return false
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
+
+// streamSSE prepares a long-lived event stream response. The server's write
+// timeout would otherwise cut the stream off.
+func streamSSE(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
@@ -18,6 +18,9 @@ func Auth(manager *auth.Manager, cfg config.AuthConfig) gin.HandlerFunc {
 		}
 
 		session, ok := manager.SessionFromRequest(c)
+		if ok {
+			session, ok = manager.RenewSession(c, session.ID)
+		}
 		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "unauthorized",
@@ -29,3 +32,23 @@ func Auth(manager *auth.Manager, cfg config.AuthConfig) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAdmin rejects requests whose session does not carry the admin role.
+// It must run after Auth.
+func RequireAdmin(cfg config.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.EnableDevBypass {
+			c.Next()
+			return
+		}
+
+		session, ok := auth.GetSession(c)
+		if !ok || !auth.IsAdmin(session) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"kubezen/internal/telemetry"
+)
+
+// Metrics records request counts and latency per route. Latency isn't
+// recorded for Server-Sent Event streams, whose duration is the connection
+// lifetime.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		duration := time.Since(start)
+		if strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "text/event-stream") {
+			duration = 0
+		}
+		telemetry.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), duration)
+	}
+}
@@ -10,40 +10,46 @@ import (
 	"kubezen/internal/auth"
 	"kubezen/internal/config"
 	"kubezen/internal/k8s"
+	"kubezen/internal/prometheus"
 	"kubezen/internal/store"
+	"kubezen/internal/telemetry"
 	"kubezen/internal/version"
 )
 
 // NewRouter wires all HTTP routes and middleware.
-func NewRouter(cfg config.Config, svc *k8s.Service, userStore *store.Store, authManager *auth.Manager, oidcClient *auth.OIDCClient) *gin.Engine {
+func NewRouter(cfg config.Config, registry *k8s.Registry, userStore *store.Store, authManager *auth.Manager, oidcClient *auth.OIDCClient, ldapClient *auth.LDAPClient, promSources *prometheus.Sources, promTemplates prometheus.Templates) *gin.Engine {
 	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery(), middleware.CORS(cfg.Server.AllowedOrigins))
-	router.GET("/healthz", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
-		})
-	})
-	router.GET("/readyz", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ready",
-		})
-	})
+	router.Use(gin.Logger(), gin.Recovery(), middleware.Metrics(), middleware.CORS(cfg.Server.AllowedOrigins))
+	// Without a separate listener, metrics are only served to token holders:
+	// they expose session counts and cluster names.
+	if cfg.Server.MetricsAddress == "" && cfg.Server.MetricsToken != "" {
+		router.GET("/metrics", gin.WrapH(telemetry.Handler(cfg.Server.MetricsToken)))
+	}
+	router.GET("/healthz", handlers.Healthz(userStore, authManager, cfg.Auth.EnableDevBypass))
+	router.GET("/readyz", handlers.Readyz(userStore, registry, authManager, cfg.Auth.EnableDevBypass))
 
 	oidcEnabled := oidcClient != nil
-	defaultContext := cfg.Kube.Context
+	ldapEnabled := ldapClient != nil
+	setupEnabled := !cfg.Bootstrap.Enabled()
+	defaultContext := registry.DefaultName()
 
 	apiGroup := router.Group("/api")
 	authGroup := apiGroup.Group("/auth")
-	authGroup.GET("/status", handlers.AuthStatus(userStore, oidcEnabled))
-	authGroup.POST("/setup", handlers.InitialSetup(userStore, authManager, defaultContext))
+	authGroup.GET("/status", handlers.AuthStatus(userStore, setupEnabled, oidcEnabled, ldapEnabled))
+	if setupEnabled {
+		authGroup.POST("/setup", handlers.InitialSetup(userStore, authManager, defaultContext))
+	}
 	authGroup.POST("/login", handlers.LocalLogin(userStore, authManager, defaultContext))
+	authGroup.POST("/ldap/login", handlers.LDAPLogin(ldapClient, authManager, defaultContext))
 	authGroup.POST("/kubeconfig", handlers.KubeconfigLogin(authManager))
 	authGroup.GET("/oidc/start", handlers.OIDCStart(authManager, oidcClient))
 	authGroup.GET("/oidc/callback", handlers.OIDCCallback(authManager, oidcClient))
+	authGroup.GET("/invites/:token", handlers.GetInvite(userStore))
+	authGroup.POST("/invites/:token/redeem", handlers.RedeemInvite(userStore, authManager, defaultContext))
 	authGroup.GET("/session", handlers.SessionInfo(authManager))
 	authGroup.POST("/logout", handlers.Logout(authManager))
 
@@ -53,19 +59,56 @@ func NewRouter(cfg config.Config, svc *k8s.Service, userStore *store.Store, auth
 	})
 
 	v1 := apiGroup.Group("/v1")
-	v1.GET("/contexts", handlers.ListContexts(cfg.Kube.KubeconfigPath, cfg.Kube.Context))
-	v1.GET("/pods", handlers.ListPods(svc))
-	v1.GET("/pods/:namespace/:name", handlers.GetPod(svc))
-	v1.GET("/nodes", handlers.ListNodes(svc))
-	v1.GET("/nodes/:name", handlers.GetNode(svc))
-	v1.GET("/deployments", handlers.ListDeployments(svc))
-	v1.GET("/deployments/:namespace/:name", handlers.GetDeployment(svc))
-	v1.GET("/namespaces", handlers.ListNamespaces(svc))
-	v1.POST("/namespaces", handlers.CreateNamespace(svc))
-	v1.DELETE("/namespaces/:name", handlers.DeleteNamespace(svc))
-	v1.GET("/events", handlers.ListEvents(svc))
+	v1.GET("/contexts", handlers.ListContexts(registry, authManager))
+	v1.POST("/contexts/switch", handlers.SwitchContext(registry, authManager))
+	registerResourceRoutes(v1, handlers.SessionCluster(registry, authManager))
+
+	v1.GET("/clusters", handlers.ListClusters(registry, authManager))
+	registerResourceRoutes(v1.Group("/clusters/:cluster"), handlers.ClusterFromPath(registry, authManager))
+	v1.GET("/fleet/pods", handlers.FleetPods(registry, authManager))
+	v1.GET("/fleet/nodes", handlers.FleetNodes(registry, authManager))
+	v1.GET("/diff", handlers.DiffWorkloads(registry, authManager))
+	v1.GET("/metrics/history", handlers.MetricsHistory(userStore, registry, authManager, cfg.Metrics))
+	v1.GET("/prometheus/charts/:kind", handlers.PrometheusCharts(promSources, promTemplates, registry, authManager, cfg.Auth.EnableDevBypass))
+
+	v1.GET("/me/sessions", handlers.ListMySessions(authManager))
+	v1.DELETE("/me/sessions/:id", handlers.RevokeMySession(authManager))
+	v1.PUT("/me/password", handlers.ChangeMyPassword(userStore, authManager))
+
+	admin := v1.Group("", middleware.RequireAdmin(cfg.Auth))
+	admin.GET("/sessions", handlers.ListSessions(authManager))
+	admin.DELETE("/sessions/:id", handlers.RevokeSession(authManager))
+	admin.GET("/users", handlers.ListUsers(userStore))
+	admin.DELETE("/users/:id", handlers.DeleteUser(userStore, authManager))
+	admin.PUT("/users/:id/password", handlers.SetUserPassword(userStore, authManager))
+	admin.GET("/invites", handlers.ListInvites(userStore))
+	admin.POST("/invites", handlers.CreateInvite(userStore, authManager, cfg.Server.PublicURL, cfg.Auth.InviteTTL))
+	admin.DELETE("/invites/:id", handlers.RevokeInvite(userStore))
+	admin.POST("/clusters", handlers.RegisterCluster(registry, userStore, authManager))
+	admin.DELETE("/clusters/:cluster", handlers.UnregisterCluster(registry, userStore))
+	admin.GET("/prometheus/query", handlers.PrometheusQuery(promSources, registry, authManager))
+	admin.GET("/cache", handlers.CacheUsage(registry))
 
 	return router
 }
 
+// registerResourceRoutes mounts the Kubernetes resource endpoints on group,
+// served by the cluster that resolve picks.
+func registerResourceRoutes(group *gin.RouterGroup, resolve handlers.ServiceResolver) {
+	group.GET("/pods", handlers.ListPods(resolve))
+	group.GET("/pods/:namespace/:name", handlers.GetPod(resolve))
+	group.GET("/nodes", handlers.ListNodes(resolve))
+	group.GET("/nodes/:name", handlers.GetNode(resolve))
+	group.GET("/deployments", handlers.ListDeployments(resolve))
+	group.GET("/deployments/:namespace/:name", handlers.GetDeployment(resolve))
+	group.GET("/deployments/:namespace/:name/rollout", handlers.RolloutStatus(resolve))
+	group.GET("/namespaces", handlers.ListNamespaces(resolve))
+	group.POST("/namespaces", handlers.CreateNamespace(resolve))
+	group.DELETE("/namespaces/:name", handlers.DeleteNamespace(resolve))
+	group.GET("/events", handlers.ListEvents(resolve))
+	group.GET("/watch", handlers.Watch(resolve))
+	group.GET("/top/pods", handlers.TopPods(resolve))
+	group.GET("/top/nodes", handlers.TopNodes(resolve))
+}
+
 
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"kubezen/internal/config"
+)
+
+var (
+	ErrLDAPInvalidCredentials = errors.New("invalid username or password")
+	ErrLDAPNoRole             = errors.New("user is not a member of any authorized group")
+)
+
+// ldapConn is the subset of *ldap.Conn used for authentication. Tests swap in
+// an in-memory directory through LDAPClient.dial.
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAPUser is the result of a successful directory login.
+type LDAPUser struct {
+	Username string
+	DN       string
+	Email    string
+	Groups   []string
+	Role     string
+}
+
+type LDAPClient struct {
+	cfg  config.AuthConfig
+	dial func() (ldapConn, error)
+}
+
+func NewLDAPClient(cfg config.AuthConfig) (*LDAPClient, error) {
+	if cfg.LDAPURL == "" || cfg.LDAPBaseDN == "" || cfg.LDAPUserFilter == "" {
+		return nil, fmt.Errorf("ldap configuration incomplete")
+	}
+	if !strings.Contains(cfg.LDAPUserFilter, "%s") {
+		return nil, fmt.Errorf("ldap user filter must contain %%s")
+	}
+	parsed, err := url.Parse(cfg.LDAPURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse ldap url: %w", err)
+	}
+
+	client := &LDAPClient{cfg: cfg}
+	client.dial = func() (ldapConn, error) {
+		tlsConfig := &tls.Config{
+			ServerName:         parsed.Hostname(),
+			InsecureSkipVerify: cfg.LDAPInsecureSkipVerify,
+		}
+		conn, err := ldap.DialURL(cfg.LDAPURL, ldap.DialWithTLSConfig(tlsConfig))
+		if err != nil {
+			return nil, fmt.Errorf("dial ldap: %w", err)
+		}
+		if cfg.LDAPTimeout > 0 {
+			conn.SetTimeout(cfg.LDAPTimeout)
+		}
+		if cfg.LDAPStartTLS && parsed.Scheme == "ldap" {
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("ldap starttls: %w", err)
+			}
+		}
+		return conn, nil
+	}
+	return client, nil
+}
+
+// Authenticate looks the user up with the service account, verifies the
+// password by binding as the user and maps directory groups to a role.
+func (c *LDAPClient) Authenticate(username, password string) (LDAPUser, error) {
+	username = strings.TrimSpace(username)
+	// An empty password would turn the verification bind into an
+	// unauthenticated bind, which most directories accept.
+	if username == "" || password == "" {
+		return LDAPUser{}, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return LDAPUser{}, err
+	}
+	defer conn.Close()
+
+	if err := c.bindServiceAccount(conn); err != nil {
+		return LDAPUser{}, err
+	}
+
+	entry, err := c.findUser(conn, username)
+	if err != nil {
+		return LDAPUser{}, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return LDAPUser{}, ErrLDAPInvalidCredentials
+		}
+		return LDAPUser{}, fmt.Errorf("ldap user bind: %w", err)
+	}
+
+	groups := entry.GetAttributeValues(c.cfg.LDAPGroupAttribute)
+	if c.cfg.LDAPGroupBaseDN != "" {
+		// Group search runs with the service account; the user may not be
+		// allowed to read group objects.
+		if err := c.bindServiceAccount(conn); err != nil {
+			return LDAPUser{}, err
+		}
+		groups, err = c.searchGroups(conn, entry.DN)
+		if err != nil {
+			return LDAPUser{}, err
+		}
+	}
+
+	role := c.roleForGroups(groups)
+	if role == "" {
+		return LDAPUser{}, ErrLDAPNoRole
+	}
+
+	name := entry.GetAttributeValue(c.cfg.LDAPUsernameAttribute)
+	if name == "" {
+		name = username
+	}
+	return LDAPUser{
+		Username: name,
+		DN:       entry.DN,
+		Email:    entry.GetAttributeValue("mail"),
+		Groups:   groups,
+		Role:     role,
+	}, nil
+}
+
+func (c *LDAPClient) bindServiceAccount(conn ldapConn) error {
+	if c.cfg.LDAPBindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(c.cfg.LDAPBindDN, c.cfg.LDAPBindPassword); err != nil {
+		return fmt.Errorf("ldap service bind: %w", err)
+	}
+	return nil
+}
+
+func (c *LDAPClient) findUser(conn ldapConn, username string) (*ldap.Entry, error) {
+	attributes := []string{"dn", "mail", c.cfg.LDAPUsernameAttribute}
+	if c.cfg.LDAPGroupAttribute != "" {
+		attributes = append(attributes, c.cfg.LDAPGroupAttribute)
+	}
+	req := ldap.NewSearchRequest(
+		c.cfg.LDAPBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, c.timeLimit(), false,
+		strings.ReplaceAll(c.cfg.LDAPUserFilter, "%s", ldap.EscapeFilter(username)),
+		attributes,
+		nil,
+	)
+	result, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("ldap user search: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		// Unknown and ambiguous users look the same as a bad password.
+		return nil, ErrLDAPInvalidCredentials
+	}
+	return result.Entries[0], nil
+}
+
+func (c *LDAPClient) searchGroups(conn ldapConn, userDN string) ([]string, error) {
+	req := ldap.NewSearchRequest(
+		c.cfg.LDAPGroupBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, c.timeLimit(), false,
+		strings.ReplaceAll(c.cfg.LDAPGroupFilter, "%s", ldap.EscapeFilter(userDN)),
+		[]string{"dn"},
+		nil,
+	)
+	result, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("ldap group search: %w", err)
+	}
+	groups := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// roleForGroups returns the most privileged role mapped from the user's
+// groups, or the default role if no group is mapped. Mapping keys match
+// either the full group DN or its CN.
+func (c *LDAPClient) roleForGroups(groups []string) string {
+	role := ""
+	for _, group := range groups {
+		for key, mapped := range c.cfg.LDAPGroupRoles {
+			if !strings.EqualFold(key, group) && !strings.EqualFold(key, groupCN(group)) {
+				continue
+			}
+			// Equal ranks are broken by name so group order doesn't matter.
+			if rank, current := roleRank(mapped), roleRank(role); role == "" || rank > current || rank == current && mapped < role {
+				role = mapped
+			}
+		}
+	}
+	if role == "" {
+		return c.cfg.LDAPDefaultRole
+	}
+	return role
+}
+
+func (c *LDAPClient) timeLimit() int {
+	return int(c.cfg.LDAPTimeout / time.Second)
+}
+
+func groupCN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return ""
+	}
+	for _, attr := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") {
+			return attr.Value
+		}
+	}
+	return ""
+}
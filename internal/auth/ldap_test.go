@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"kubezen/internal/config"
+)
+
+// fakeDirectory is a minimal in-memory LDAP server: users are matched by the
+// value of their uid inside the search filter, groups by member DN.
+type fakeDirectory struct {
+	serviceDN  string
+	servicePW  string
+	users      []*ldap.Entry
+	passwords  map[string]string
+	groups     map[string][]string // group DN -> member DNs
+	boundAs    string
+	groupBinds []string
+}
+
+func (d *fakeDirectory) Bind(username, password string) error {
+	if username == d.serviceDN && password == d.servicePW {
+		d.boundAs = username
+		return nil
+	}
+	if pw, ok := d.passwords[username]; ok && pw == password {
+		d.boundAs = username
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("bad credentials"))
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	result := &ldap.SearchResult{}
+	if strings.HasPrefix(req.Filter, "(member=") {
+		d.groupBinds = append(d.groupBinds, d.boundAs)
+		member := strings.TrimSuffix(strings.TrimPrefix(req.Filter, "(member="), ")")
+		for dn, members := range d.groups {
+			for _, m := range members {
+				if m == member {
+					result.Entries = append(result.Entries, ldap.NewEntry(dn, nil))
+				}
+			}
+		}
+		return result, nil
+	}
+	for _, entry := range d.users {
+		if strings.Contains(req.Filter, "="+entry.GetAttributeValue("uid")+")") {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (d *fakeDirectory) Close() error { return nil }
+
+func newFakeLDAPClient(t *testing.T, cfg config.AuthConfig, dir *fakeDirectory) *LDAPClient {
+	t.Helper()
+	cfg.LDAPURL = "ldap://localhost:389"
+	cfg.LDAPBaseDN = "dc=corp,dc=local"
+	cfg.LDAPBindDN = dir.serviceDN
+	cfg.LDAPBindPassword = dir.servicePW
+	if cfg.LDAPUserFilter == "" {
+		cfg.LDAPUserFilter = "(uid=%s)"
+	}
+	cfg.LDAPUsernameAttribute = "uid"
+	client, err := NewLDAPClient(cfg)
+	if err != nil {
+		t.Fatalf("new ldap client: %v", err)
+	}
+	client.dial = func() (ldapConn, error) { return dir, nil }
+	return client
+}
+
+func newTestDirectory() *fakeDirectory {
+	aliceDN := "uid=alice,ou=people,dc=corp,dc=local"
+	bobDN := "uid=bob,ou=people,dc=corp,dc=local"
+	return &fakeDirectory{
+		serviceDN: "cn=svc,dc=corp,dc=local",
+		servicePW: "svc-secret",
+		users: []*ldap.Entry{
+			ldap.NewEntry(aliceDN, map[string][]string{
+				"uid":      {"alice"},
+				"mail":     {"alice@corp.local"},
+				"memberOf": {"CN=kz-admins,OU=Groups,DC=corp,DC=local", "CN=staff,OU=Groups,DC=corp,DC=local"},
+			}),
+			ldap.NewEntry(bobDN, map[string][]string{
+				"uid":      {"bob"},
+				"memberOf": {"CN=staff,OU=Groups,DC=corp,DC=local"},
+			}),
+		},
+		passwords: map[string]string{aliceDN: "alice-pw", bobDN: "bob-pw"},
+		groups: map[string][]string{
+			"cn=kz-users,ou=groups,dc=corp,dc=local": {bobDN},
+		},
+	}
+}
+
+func TestLDAPAuthenticateMapsGroupsToRole(t *testing.T) {
+	client := newFakeLDAPClient(t, config.AuthConfig{
+		LDAPGroupAttribute: "memberOf",
+		LDAPGroupRoles:     map[string]string{"kz-admins": "admin", "staff": "user"},
+	}, newTestDirectory())
+
+	user, err := client.Authenticate("alice", "alice-pw")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if user.Role != "admin" || user.Email != "alice@corp.local" || len(user.Groups) != 2 {
+		t.Fatalf("unexpected user: %#v", user)
+	}
+
+	if _, err := client.Authenticate("alice", "wrong"); !errors.Is(err, ErrLDAPInvalidCredentials) {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	if _, err := client.Authenticate("alice", ""); !errors.Is(err, ErrLDAPInvalidCredentials) {
+		t.Fatalf("expected empty password to be rejected, got %v", err)
+	}
+	if _, err := client.Authenticate("mallory", "x"); !errors.Is(err, ErrLDAPInvalidCredentials) {
+		t.Fatalf("expected unknown user to be rejected, got %v", err)
+	}
+}
+
+func TestLDAPMappedRolesOverrideDefault(t *testing.T) {
+	client := newFakeLDAPClient(t, config.AuthConfig{
+		LDAPGroupAttribute: "memberOf",
+		LDAPGroupRoles:     map[string]string{"kz-admins": "admin", "staff": "viewer"},
+		LDAPDefaultRole:    "user",
+	}, newTestDirectory())
+
+	for username, want := range map[string]string{"alice": "admin", "bob": "viewer"} {
+		user, err := client.Authenticate(username, username+"-pw")
+		if err != nil {
+			t.Fatalf("authenticate %s: %v", username, err)
+		}
+		if user.Role != want {
+			t.Fatalf("expected %s to get %q, got %q", username, want, user.Role)
+		}
+	}
+
+	client.cfg.LDAPGroupRoles = map[string]string{"kz-admins": "admin"}
+	if user, err := client.Authenticate("bob", "bob-pw"); err != nil || user.Role != "user" {
+		t.Fatalf("expected the default role without a mapped group, got %+v %v", user, err)
+	}
+}
+
+func TestLDAPAuthenticateRequiresMappedGroup(t *testing.T) {
+	client := newFakeLDAPClient(t, config.AuthConfig{
+		LDAPGroupAttribute: "memberOf",
+		LDAPGroupRoles:     map[string]string{"kz-admins": "admin"},
+	}, newTestDirectory())
+
+	if _, err := client.Authenticate("bob", "bob-pw"); !errors.Is(err, ErrLDAPNoRole) {
+		t.Fatalf("expected no role error, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticateGroupSearch(t *testing.T) {
+	dir := newTestDirectory()
+	client := newFakeLDAPClient(t, config.AuthConfig{
+		LDAPGroupBaseDN: "ou=groups,dc=corp,dc=local",
+		LDAPGroupFilter: "(member=%s)",
+		LDAPGroupRoles:  map[string]string{"cn=kz-users,ou=groups,dc=corp,dc=local": "user"},
+	}, dir)
+
+	user, err := client.Authenticate("bob", "bob-pw")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if user.Role != "user" {
+		t.Fatalf("expected user role, got %q", user.Role)
+	}
+	if len(dir.groupBinds) != 1 || dir.groupBinds[0] != dir.serviceDN {
+		t.Fatalf("expected group search as service account, got %v", dir.groupBinds)
+	}
+}
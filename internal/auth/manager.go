@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -19,19 +21,34 @@ const (
 	SourceOIDC       SessionSource = "oidc"
 	SourceKubeconfig SessionSource = "kubeconfig"
 	SourceLocal      SessionSource = "local"
+	SourceLDAP       SessionSource = "ldap"
 )
 
 type Session struct {
 	ID           string
+	PublicID     string // non-secret handle used when listing and revoking sessions
 	Source       SessionSource
 	Subject      string
+	Role         string
 	AccessToken  string
 	RefreshToken string
 	TokenType    string
-	ExpiresAt    time.Time
+	TokenExpiry  time.Time // OIDC access token expiry
+	ExpiresAt    time.Time // session expiry, slides forward on activity
 	Kubeconfig   string
 	Context      string
 	CreatedAt    time.Time
+	LastSeen     time.Time
+	ClientIP     string
+	UserAgent    string
+}
+
+// stateTTL bounds how long an OIDC login may take between start and callback.
+const stateTTL = 10 * time.Minute
+
+type pkceVerifier struct {
+	verifier  string
+	expiresAt time.Time
 }
 
 // Manager holds in-memory sessions and OIDC state for CSRF protection.
@@ -40,7 +57,7 @@ type Manager struct {
 	mu                sync.RWMutex
 	sessions          map[string]Session
 	stateStore        map[string]time.Time
-	codeVerifierStore map[string]string // state -> codeVerifier for PKCE
+	codeVerifierStore map[string]pkceVerifier // state -> codeVerifier for PKCE
 }
 
 func NewManager(cfg config.AuthConfig) *Manager {
@@ -48,98 +65,254 @@ func NewManager(cfg config.AuthConfig) *Manager {
 		cfg:               cfg,
 		sessions:          make(map[string]Session),
 		stateStore:        make(map[string]time.Time),
-		codeVerifierStore: make(map[string]string),
+		codeVerifierStore: make(map[string]pkceVerifier),
 	}
 }
 
 func (m *Manager) NewSessionFromOIDC(subject string, token OIDCTokenPayload) Session {
-	id := newID()
-	s := Session{
-		ID:           id,
+	return m.addSession(Session{
 		Source:       SourceOIDC,
 		Subject:      subject,
+		Role:         m.cfg.OIDCRole,
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		TokenType:    token.TokenType,
-		ExpiresAt:    token.Expiry,
+		TokenExpiry:  token.Expiry,
 		Context:      m.cfg.DefaultContext, // Use backend's kube context
-		CreatedAt:    time.Now(),
-	}
-	m.mu.Lock()
-	m.sessions[id] = s
-	m.mu.Unlock()
-	return s
+	})
 }
 
 func (m *Manager) NewSessionFromKubeconfig(subject, rawConfig, context string) Session {
-	id := newID()
-	s := Session{
-		ID:          id,
-		Source:      SourceKubeconfig,
-		Subject:     subject,
-		Kubeconfig:  rawConfig,
-		Context:     context,
-		ExpiresAt:   time.Now().Add(m.cfg.SessionTTL),
-		CreatedAt:   time.Now(),
-		AccessToken: "",
-	}
-	m.mu.Lock()
-	m.sessions[id] = s
-	m.mu.Unlock()
-	return s
+	return m.addSession(Session{
+		Source:     SourceKubeconfig,
+		Subject:    subject,
+		Role:       m.cfg.KubeconfigRole,
+		Kubeconfig: rawConfig,
+		Context:    context,
+	})
 }
 
 func (m *Manager) NewSessionFromLocal(username, role, context string) Session {
-	id := newID()
-	s := Session{
-		ID:        id,
-		Source:    SourceLocal,
-		Subject:   username,
-		Context:   context,
-		ExpiresAt: time.Now().Add(m.cfg.SessionTTL),
-		CreatedAt: time.Now(),
-	}
+	return m.addSession(Session{
+		Source:  SourceLocal,
+		Subject: username,
+		Role:    role,
+		Context: context,
+	})
+}
+
+func (m *Manager) NewSessionFromLDAP(user LDAPUser, context string) Session {
+	return m.addSession(Session{
+		Source:  SourceLDAP,
+		Subject: user.Username,
+		Role:    user.Role,
+		Context: context,
+	})
+}
+
+// addSession assigns identifiers and timestamps and stores the session.
+func (m *Manager) addSession(s Session) Session {
+	now := time.Now()
+	s.ID = newID()
+	s.PublicID = newID()
+	s.CreatedAt = now
+	s.LastSeen = now
+	s.ExpiresAt = m.expiryFor(s, now)
 	m.mu.Lock()
-	m.sessions[id] = s
+	m.sessions[s.ID] = s
 	m.mu.Unlock()
 	return s
 }
 
+// expiryFor returns when a session active at now expires: after the idle
+// timeout, but never past the absolute lifetime measured from creation.
+func (m *Manager) expiryFor(s Session, now time.Time) time.Time {
+	var expiry time.Time
+	if m.cfg.SessionTTL > 0 {
+		expiry = s.CreatedAt.Add(m.cfg.SessionTTL)
+	}
+	if m.cfg.SessionIdleTimeout > 0 {
+		idle := now.Add(m.cfg.SessionIdleTimeout)
+		if expiry.IsZero() || idle.Before(expiry) {
+			expiry = idle
+		}
+	}
+	return expiry
+}
+
 func (m *Manager) SessionByID(id string) (Session, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	s, ok := m.sessions[id]
-	if !ok {
-		return Session{}, false
-	}
-	if s.ExpiresAt.Before(time.Now()) && s.Source != SourceOIDC {
+	if !ok || sessionExpired(s, time.Now()) {
 		return Session{}, false
 	}
 	return s, true
 }
 
+func sessionExpired(s Session, now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
 func (m *Manager) DeleteSession(id string) {
 	m.mu.Lock()
 	delete(m.sessions, id)
 	m.mu.Unlock()
 }
 
+// SetSessionContext points a session at another cluster context.
+func (m *Manager) SetSessionContext(id, context string) (Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || sessionExpired(s, time.Now()) {
+		return Session{}, false
+	}
+	s.Context = context
+	m.sessions[id] = s
+	return s, true
+}
+
+// CanAccessCluster reports whether the session's role meets the minimum role
+// configured for the cluster.
+func (m *Manager) CanAccessCluster(s Session, cluster string) bool {
+	required, ok := m.cfg.ClusterRoles[cluster]
+	if !ok {
+		return true
+	}
+	return roleRank(s.Role) >= roleRank(required)
+}
+
+// IsAdmin reports whether the session's role grants admin access, whichever
+// source the session came from.
+func IsAdmin(s Session) bool {
+	return roleRank(s.Role) >= roleRank("admin")
+}
+
+func roleRank(role string) int {
+	switch role {
+	case "admin":
+		return 2
+	case "user":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Touch records activity on a session and slides its expiry forward.
+func (m *Manager) Touch(id string) (Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	now := time.Now()
+	if !ok || sessionExpired(s, now) {
+		return Session{}, false
+	}
+	s.LastSeen = now
+	s.ExpiresAt = m.expiryFor(s, now)
+	m.sessions[id] = s
+	return s, true
+}
+
+// RenewSession touches the session and re-issues its cookie so the browser
+// keeps it for as long as the server does.
+func (m *Manager) RenewSession(c *gin.Context, id string) (Session, bool) {
+	s, ok := m.Touch(id)
+	if !ok {
+		return Session{}, false
+	}
+	m.writeCookie(c, id, s.ExpiresAt)
+	return s, true
+}
+
+// ListSessions returns all sessions, oldest first.
+func (m *Manager) ListSessions() []Session {
+	m.mu.RLock()
+	out := make([]Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		out = append(out, s)
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// SessionsForSubject returns the sessions a user holds for one login source.
+func (m *Manager) SessionsForSubject(source SessionSource, subject string) []Session {
+	all := m.ListSessions()
+	out := all[:0]
+	for _, s := range all {
+		if s.Source == source && s.Subject == subject {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// RevokeSession deletes the session with the given public ID.
+func (m *Manager) RevokeSession(publicID string) (Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.sessions {
+		if s.PublicID == publicID {
+			delete(m.sessions, id)
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// RevokeSubjectSessions deletes every session of a user except the one with
+// ID keep (may be empty) and returns how many were removed.
+func (m *Manager) RevokeSubjectSessions(source SessionSource, subject, keep string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revoked := 0
+	for id, s := range m.sessions {
+		if s.Source == source && s.Subject == subject && id != keep {
+			delete(m.sessions, id)
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// WriteSessionCookie issues the session cookie and records the client the
+// session was handed to.
 func (m *Manager) WriteSessionCookie(c *gin.Context, sessionID string) {
-	httpOnly := true
-	secure := m.cfg.SessionSecure
-	sameSite := http.SameSiteLaxMode
-	domain := m.cfg.SessionDomain
+	m.mu.Lock()
+	s, ok := m.sessions[sessionID]
+	if ok {
+		s.ClientIP = c.ClientIP()
+		s.UserAgent = c.Request.UserAgent()
+		m.sessions[sessionID] = s
+	}
+	m.mu.Unlock()
 
+	m.writeCookie(c, sessionID, s.ExpiresAt)
+}
+
+func (m *Manager) writeCookie(c *gin.Context, sessionID string, expiresAt time.Time) {
+	// Without any expiry configured the cookie lives for the browser session.
+	maxAge := 0
+	if !expiresAt.IsZero() {
+		maxAge = int(time.Until(expiresAt).Seconds())
+		if maxAge <= 0 {
+			maxAge = -1
+		}
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
 	c.SetCookie(
 		m.cookieName(),
 		sessionID,
-		int(m.cfg.SessionTTL.Seconds()),
+		maxAge,
 		"/",
-		domain,
-		secure,
-		httpOnly,
+		m.cfg.SessionDomain,
+		m.cfg.SessionSecure,
+		true,
 	)
-	c.SetSameSite(sameSite)
 }
 
 func (m *Manager) ClearSessionCookie(c *gin.Context) {
@@ -159,7 +332,7 @@ func (m *Manager) SessionFromRequest(c *gin.Context) (Session, bool) {
 func (m *Manager) NewState() string {
 	state := newID()
 	m.mu.Lock()
-	m.stateStore[state] = time.Now().Add(10 * time.Minute)
+	m.stateStore[state] = time.Now().Add(stateTTL)
 	m.mu.Unlock()
 	return state
 }
@@ -178,7 +351,7 @@ func (m *Manager) ValidateState(state string) bool {
 // StoreCodeVerifier saves the PKCE code verifier for a given state
 func (m *Manager) StoreCodeVerifier(state, verifier string) {
 	m.mu.Lock()
-	m.codeVerifierStore[state] = verifier
+	m.codeVerifierStore[state] = pkceVerifier{verifier: verifier, expiresAt: time.Now().Add(stateTTL)}
 	m.mu.Unlock()
 }
 
@@ -186,9 +359,59 @@ func (m *Manager) StoreCodeVerifier(state, verifier string) {
 func (m *Manager) GetCodeVerifier(state string) string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	verifier := m.codeVerifierStore[state]
+	entry, ok := m.codeVerifierStore[state]
 	delete(m.codeVerifierStore, state)
-	return verifier
+	if !ok || time.Now().After(entry.expiresAt) {
+		return ""
+	}
+	return entry.verifier
+}
+
+// Janitor -------------------------------------------------------------------
+
+// RunJanitor periodically purges expired sessions, OIDC states and PKCE
+// verifiers until ctx is cancelled.
+func (m *Manager) RunJanitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Cleanup()
+		}
+	}
+}
+
+// Cleanup removes everything that has expired and returns how many sessions
+// were dropped.
+func (m *Manager) Cleanup() int {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for id, s := range m.sessions {
+		if sessionExpired(s, now) {
+			delete(m.sessions, id)
+			removed++
+		}
+	}
+	for state, expiry := range m.stateStore {
+		if now.After(expiry) {
+			delete(m.stateStore, state)
+		}
+	}
+	for state, entry := range m.codeVerifierStore {
+		if now.After(entry.expiresAt) {
+			delete(m.codeVerifierStore, state)
+		}
+	}
+	return removed
 }
 
 func (m *Manager) cookieName() string {
@@ -33,3 +33,140 @@ func TestSessionLifecycle(t *testing.T) {
 		t.Fatalf("session should be deleted")
 	}
 }
+
+func TestRevokeSessions(t *testing.T) {
+	m := NewManager(config.AuthConfig{SessionTTL: time.Hour})
+	first := m.NewSessionFromLocal("alice", "admin", "ctx")
+	second := m.NewSessionFromLocal("alice", "admin", "ctx")
+	other := m.NewSessionFromLocal("bob", "user", "ctx")
+
+	if got := len(m.SessionsForSubject(SourceLocal, "alice")); got != 2 {
+		t.Fatalf("expected 2 sessions for alice, got %d", got)
+	}
+	if first.PublicID == "" || first.PublicID == first.ID {
+		t.Fatalf("expected a separate public ID")
+	}
+
+	if _, ok := m.RevokeSession(other.PublicID); !ok {
+		t.Fatalf("expected session to be revoked by public ID")
+	}
+	if _, ok := m.SessionByID(other.ID); ok {
+		t.Fatalf("revoked session should be gone")
+	}
+
+	if n := m.RevokeSubjectSessions(SourceLocal, "alice", second.ID); n != 1 {
+		t.Fatalf("expected 1 revoked session, got %d", n)
+	}
+	if _, ok := m.SessionByID(second.ID); !ok {
+		t.Fatalf("kept session should survive")
+	}
+}
+
+func TestSessionIdleAndAbsoluteTimeouts(t *testing.T) {
+	m := NewManager(config.AuthConfig{SessionTTL: time.Hour, SessionIdleTimeout: 10 * time.Minute})
+	session := m.NewSessionFromLocal("alice", "user", "ctx")
+	if got := session.ExpiresAt.Sub(session.CreatedAt); got != 10*time.Minute {
+		t.Fatalf("expected idle expiry, got %s", got)
+	}
+
+	// Activity close to the absolute limit is capped by it.
+	m.mu.Lock()
+	s := m.sessions[session.ID]
+	s.CreatedAt = time.Now().Add(-55 * time.Minute)
+	m.sessions[session.ID] = s
+	m.mu.Unlock()
+	touched, ok := m.Touch(session.ID)
+	if !ok {
+		t.Fatalf("expected session to be renewed")
+	}
+	if want := touched.CreatedAt.Add(time.Hour); !touched.ExpiresAt.Equal(want) {
+		t.Fatalf("expected expiry capped at %s, got %s", want, touched.ExpiresAt)
+	}
+
+	// An idle session is rejected and cannot be renewed.
+	m.mu.Lock()
+	s = m.sessions[session.ID]
+	s.ExpiresAt = time.Now().Add(-time.Second)
+	m.sessions[session.ID] = s
+	m.mu.Unlock()
+	if _, ok := m.SessionByID(session.ID); ok {
+		t.Fatalf("expected idle session to be expired")
+	}
+	if _, ok := m.Touch(session.ID); ok {
+		t.Fatalf("expired session must not be renewed")
+	}
+}
+
+func TestCleanupPurgesExpiredEntries(t *testing.T) {
+	m := NewManager(config.AuthConfig{SessionTTL: time.Hour})
+	live := m.NewSessionFromLocal("alice", "user", "ctx")
+	stale := m.NewSessionFromLocal("bob", "user", "ctx")
+	state := m.NewState()
+	m.StoreCodeVerifier(state, "verifier")
+	m.StoreCodeVerifier("orphan", "verifier")
+
+	m.mu.Lock()
+	s := m.sessions[stale.ID]
+	s.ExpiresAt = time.Now().Add(-time.Second)
+	m.sessions[stale.ID] = s
+	m.stateStore[state] = time.Now().Add(-time.Second)
+	m.codeVerifierStore["orphan"] = pkceVerifier{verifier: "verifier", expiresAt: time.Now().Add(-time.Second)}
+	m.mu.Unlock()
+
+	if removed := m.Cleanup(); removed != 1 {
+		t.Fatalf("expected 1 session removed, got %d", removed)
+	}
+	if _, ok := m.SessionByID(live.ID); !ok {
+		t.Fatalf("live session should survive cleanup")
+	}
+	if len(m.stateStore) != 0 || len(m.codeVerifierStore) != 1 {
+		t.Fatalf("unexpected state=%d verifiers=%d", len(m.stateStore), len(m.codeVerifierStore))
+	}
+}
+
+func TestSessionContextSwitchAndClusterAccess(t *testing.T) {
+	m := NewManager(config.AuthConfig{
+		SessionTTL:     time.Hour,
+		ClusterRoles:   map[string]string{"prod": "admin", "staging": "user"},
+		OIDCRole:       "user",
+		KubeconfigRole: "admin",
+	})
+	user := m.NewSessionFromLocal("alice", "user", "dev")
+	admin := m.NewSessionFromLocal("root", "admin", "dev")
+	oidc := m.NewSessionFromOIDC("bob", OIDCTokenPayload{})
+	kubeconfig := m.NewSessionFromKubeconfig("carol", "raw-config", "dev")
+	ldapUser := m.NewSessionFromLDAP(LDAPUser{Username: "dave", Role: "user"}, "dev")
+
+	switched, ok := m.SetSessionContext(user.ID, "staging")
+	if !ok || switched.Context != "staging" {
+		t.Fatalf("expected context switch, got %+v", switched)
+	}
+	if found, _ := m.SessionByID(user.ID); found.Context != "staging" {
+		t.Fatalf("context switch not persisted")
+	}
+
+	cases := []struct {
+		session Session
+		cluster string
+		want    bool
+	}{
+		{user, "dev", true},
+		{user, "staging", true},
+		{user, "prod", false},
+		{admin, "prod", true},
+		{oidc, "staging", true},
+		{oidc, "prod", false},
+		{kubeconfig, "prod", true},
+		{ldapUser, "staging", true},
+		{ldapUser, "prod", false},
+	}
+	for _, tc := range cases {
+		if got := m.CanAccessCluster(tc.session, tc.cluster); got != tc.want {
+			t.Errorf("%s on %s: got %v, want %v", tc.session.Subject, tc.cluster, got, tc.want)
+		}
+	}
+
+	if !IsAdmin(kubeconfig) || IsAdmin(oidc) || IsAdmin(user) {
+		t.Errorf("expected admin access to follow the mapped roles")
+	}
+}
@@ -9,10 +9,15 @@ import (
 
 // Config holds all runtime configuration for the backend server.
 type Config struct {
-	Env    string
-	Server ServerConfig
-	Kube   KubeConfig
-	Auth   AuthConfig
+	Env       string
+	Server    ServerConfig
+	Kube      KubeConfig
+	Auth      AuthConfig
+	Bootstrap BootstrapConfig
+	Metrics   MetricsConfig
+	Store     StoreConfig
+
+	Prometheus PrometheusConfig
 }
 
 type ServerConfig struct {
@@ -20,6 +25,12 @@ type ServerConfig struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	AllowedOrigins []string
+	PublicURL      string // external UI base URL used in generated links
+	// /metrics is served on MetricsAddress if set, otherwise on Address but
+	// only with MetricsToken set. A set MetricsToken is required as bearer
+	// token on either.
+	MetricsAddress string
+	MetricsToken   string
 }
 
 type KubeConfig struct {
@@ -28,6 +39,25 @@ type KubeConfig struct {
 	QPS                   float32
 	Burst                 int
 	InsecureSkipTLSVerify bool
+	SyncTimeout           time.Duration // bound on the initial informer sync per cluster
+	FleetTimeout          time.Duration // per-cluster bound for fleet-wide views
+	// StaleThreshold restarts a cluster's informers once a cache saw no
+	// events or watch renewals for this long. Zero disables the watchdog.
+	StaleThreshold time.Duration
+	// Namespaces limits the caches to these namespaces, for credentials
+	// without cluster-wide read access. Empty watches the whole cluster.
+	Namespaces []string
+	// Informers start when a resource type is first requested; requests
+	// wait up to InformerSyncTimeout for the cache, and informers unused for
+	// InformerIdleTimeout are stopped (zero keeps them).
+	InformerSyncTimeout time.Duration
+	InformerIdleTimeout time.Duration
+	// Opt-in cache trimming on top of managedFields: kubectl's last-applied
+	// annotation, pod container env with more than TrimEnvOver entries
+	// (zero keeps env) and Secret values.
+	TrimLastApplied bool
+	TrimEnvOver     int
+	TrimSecretData  bool
 }
 
 type AuthConfig struct {
@@ -35,7 +65,7 @@ type AuthConfig struct {
 	EnableDevBypass  bool
 	SessionName      string
 	SessionSecret    string
-	SessionTTL       time.Duration
+	SessionTTL       time.Duration // absolute lifetime, measured from login
 	SessionSecure    bool
 	SessionDomain    string
 	DefaultContext   string // Kube context to show in UI for OIDC sessions
@@ -44,6 +74,84 @@ type AuthConfig struct {
 	OIDCClientSecret string
 	OIDCRedirectURL  string
 	OIDCScopes       []string
+
+	// SessionIdleTimeout expires sessions without activity; zero disables it.
+	SessionIdleTimeout     time.Duration
+	SessionCleanupInterval time.Duration
+	InviteTTL              time.Duration
+	// ClusterRoles maps a cluster name to the minimum role allowed to use it;
+	// unlisted clusters are open to every authenticated user.
+	ClusterRoles map[string]string
+	// Roles given to OIDC and kubeconfig sessions, which carry none of their own.
+	OIDCRole       string
+	KubeconfigRole string
+
+	// LDAP settings; LDAPURL enables the backend (e.g. ldaps://dc.corp.local:636).
+	LDAPURL                string
+	LDAPStartTLS           bool
+	LDAPInsecureSkipVerify bool
+	LDAPBindDN             string
+	LDAPBindPassword       string
+	LDAPBaseDN             string
+	LDAPUserFilter         string // %s is replaced with the escaped username
+	LDAPUsernameAttribute  string
+	LDAPGroupAttribute     string // membership attribute on the user entry (memberOf)
+	LDAPGroupBaseDN        string // optional group search for servers without memberOf
+	LDAPGroupFilter        string // %s is replaced with the escaped user DN
+	LDAPGroupRoles         map[string]string
+	LDAPDefaultRole        string // role for users matching no group; empty denies login
+	LDAPTimeout            time.Duration
+}
+
+// StoreConfig holds settings of the SQLite store. EncryptionKey encrypts the
+// kubeconfigs of clusters registered through the API; without it clusters
+// can't be registered through the API.
+type StoreConfig struct {
+	EncryptionKey string
+}
+
+// MetricsConfig controls the built-in usage history. Raw samples are kept
+// for HistoryRawRetention, then averaged into HistoryRollup buckets that are
+// kept for HistoryRetention.
+type MetricsConfig struct {
+	HistoryEnabled      bool
+	HistoryInterval     time.Duration
+	HistoryRollup       time.Duration
+	HistoryRawRetention time.Duration
+	HistoryRetention    time.Duration
+}
+
+// PrometheusConfig points chart endpoints at Prometheus-compatible APIs.
+// ClusterURLs overrides URL per registered cluster.
+type PrometheusConfig struct {
+	URL                string
+	ClusterURLs        map[string]string
+	BearerTokenFile    string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	// TemplatesFile overrides or extends the built-in PromQL templates.
+	TemplatesFile string
+}
+
+// Enabled reports whether any Prometheus data source is configured.
+func (p PrometheusConfig) Enabled() bool {
+	return p.URL != "" || len(p.ClusterURLs) > 0
+}
+
+// BootstrapConfig seeds local users at startup so installs need no setup wizard.
+type BootstrapConfig struct {
+	AdminUsername     string
+	AdminPasswordHash string // bcrypt hash
+	AdminPasswordFile string // mounted secret holding a password or bcrypt hash
+	UsersFile         string // declarative user list (YAML or JSON)
+	// Reconcile updates listed users and deletes unlisted ones on every boot.
+	Reconcile bool
+}
+
+// Enabled reports whether users are provisioned from configuration, in which
+// case the interactive setup endpoint is disabled.
+func (b BootstrapConfig) Enabled() bool {
+	return b.AdminUsername != "" || b.UsersFile != ""
 }
 
 // Load builds a Config from environment variables with reasonable defaults.
@@ -55,6 +163,9 @@ func Load() Config {
 			ReadTimeout:    getDuration("KZ_READ_TIMEOUT", 15*time.Second),
 			WriteTimeout:   getDuration("KZ_WRITE_TIMEOUT", 15*time.Second),
 			AllowedOrigins: splitCSV(getEnv("KZ_ALLOWED_ORIGINS", "*")),
+			PublicURL:      strings.TrimSuffix(getEnv("KZ_PUBLIC_URL", ""), "/"),
+			MetricsAddress: getEnv("KZ_METRICS_ADDRESS", ""),
+			MetricsToken:   getEnv("KZ_METRICS_TOKEN", ""),
 		},
 		Kube: KubeConfig{
 			KubeconfigPath:        expandTilde(getEnv("KZ_KUBECONFIG", os.Getenv("KUBECONFIG"))),
@@ -62,6 +173,15 @@ func Load() Config {
 			QPS:                   getFloat32("KZ_KUBE_QPS", 20),
 			Burst:                 getInt("KZ_KUBE_BURST", 40),
 			InsecureSkipTLSVerify: getBool("KZ_KUBE_INSECURE", false),
+			SyncTimeout:           getDuration("KZ_KUBE_SYNC_TIMEOUT", 2*time.Minute),
+			FleetTimeout:          getDuration("KZ_KUBE_FLEET_TIMEOUT", 10*time.Second),
+			StaleThreshold:        getDuration("KZ_KUBE_STALE_THRESHOLD", 15*time.Minute),
+			Namespaces:            splitList(getEnv("KZ_KUBE_NAMESPACES", "")),
+			InformerSyncTimeout:   getDuration("KZ_KUBE_INFORMER_SYNC_TIMEOUT", 30*time.Second),
+			InformerIdleTimeout:   getDuration("KZ_KUBE_INFORMER_IDLE_TIMEOUT", 10*time.Minute),
+			TrimLastApplied:       getBool("KZ_KUBE_TRIM_LAST_APPLIED", false),
+			TrimEnvOver:           getInt("KZ_KUBE_TRIM_ENV_OVER", 0),
+			TrimSecretData:        getBool("KZ_KUBE_TRIM_SECRET_DATA", false),
 		},
 		Auth: AuthConfig{
 			EnableDevBypass:  getBool("KZ_AUTH_DEV_BYPASS", true),
@@ -76,6 +196,53 @@ func Load() Config {
 			OIDCClientSecret: getEnv("KZ_AUTH_OIDC_CLIENT_SECRET", ""),
 			OIDCRedirectURL:  getEnv("KZ_AUTH_OIDC_REDIRECT_URL", ""),
 			OIDCScopes:       splitCSV(getEnv("KZ_AUTH_OIDC_SCOPES", "openid,profile,email")),
+
+			SessionIdleTimeout:     getDuration("KZ_AUTH_SESSION_IDLE_TIMEOUT", 2*time.Hour),
+			SessionCleanupInterval: getDuration("KZ_AUTH_SESSION_CLEANUP_INTERVAL", 5*time.Minute),
+			InviteTTL:              getDuration("KZ_AUTH_INVITE_TTL", 72*time.Hour),
+			ClusterRoles:           splitMapping(getEnv("KZ_AUTH_CLUSTER_ROLES", "")),
+			OIDCRole:               getEnv("KZ_AUTH_OIDC_ROLE", "user"),
+			KubeconfigRole:         getEnv("KZ_AUTH_KUBECONFIG_ROLE", "user"),
+
+			LDAPURL:                getEnv("KZ_AUTH_LDAP_URL", ""),
+			LDAPStartTLS:           getBool("KZ_AUTH_LDAP_START_TLS", false),
+			LDAPInsecureSkipVerify: getBool("KZ_AUTH_LDAP_INSECURE", false),
+			LDAPBindDN:             getEnv("KZ_AUTH_LDAP_BIND_DN", ""),
+			LDAPBindPassword:       getEnv("KZ_AUTH_LDAP_BIND_PASSWORD", ""),
+			LDAPBaseDN:             getEnv("KZ_AUTH_LDAP_BASE_DN", ""),
+			LDAPUserFilter:         getEnv("KZ_AUTH_LDAP_USER_FILTER", "(uid=%s)"),
+			LDAPUsernameAttribute:  getEnv("KZ_AUTH_LDAP_USERNAME_ATTRIBUTE", "uid"),
+			LDAPGroupAttribute:     getEnv("KZ_AUTH_LDAP_GROUP_ATTRIBUTE", "memberOf"),
+			LDAPGroupBaseDN:        getEnv("KZ_AUTH_LDAP_GROUP_BASE_DN", ""),
+			LDAPGroupFilter:        getEnv("KZ_AUTH_LDAP_GROUP_FILTER", "(member=%s)"),
+			LDAPGroupRoles:         splitMapping(getEnv("KZ_AUTH_LDAP_GROUP_ROLES", "")),
+			LDAPDefaultRole:        getEnv("KZ_AUTH_LDAP_DEFAULT_ROLE", ""),
+			LDAPTimeout:            getDuration("KZ_AUTH_LDAP_TIMEOUT", 10*time.Second),
+		},
+		Bootstrap: BootstrapConfig{
+			AdminUsername:     getEnv("KZ_BOOTSTRAP_ADMIN_USERNAME", ""),
+			AdminPasswordHash: getEnv("KZ_BOOTSTRAP_ADMIN_PASSWORD_HASH", ""),
+			AdminPasswordFile: expandTilde(getEnv("KZ_BOOTSTRAP_ADMIN_PASSWORD_FILE", "")),
+			UsersFile:         expandTilde(getEnv("KZ_BOOTSTRAP_USERS_FILE", "")),
+			Reconcile:         getBool("KZ_BOOTSTRAP_RECONCILE", false),
+		},
+		Metrics: MetricsConfig{
+			HistoryEnabled:      getBool("KZ_METRICS_HISTORY_ENABLED", true),
+			HistoryInterval:     getDuration("KZ_METRICS_HISTORY_INTERVAL", 30*time.Second),
+			HistoryRollup:       getDuration("KZ_METRICS_HISTORY_ROLLUP", 5*time.Minute),
+			HistoryRawRetention: getDuration("KZ_METRICS_HISTORY_RAW_RETENTION", 2*time.Hour),
+			HistoryRetention:    getDuration("KZ_METRICS_HISTORY_RETENTION", 48*time.Hour),
+		},
+		Store: StoreConfig{
+			EncryptionKey: getEnv("KZ_STORE_ENCRYPTION_KEY", ""),
+		},
+		Prometheus: PrometheusConfig{
+			URL:                strings.TrimSuffix(getEnv("KZ_PROMETHEUS_URL", ""), "/"),
+			ClusterURLs:        splitURLMapping(getEnv("KZ_PROMETHEUS_CLUSTER_URLS", "")),
+			BearerTokenFile:    expandTilde(getEnv("KZ_PROMETHEUS_BEARER_TOKEN_FILE", "")),
+			InsecureSkipVerify: getBool("KZ_PROMETHEUS_INSECURE", false),
+			Timeout:            getDuration("KZ_PROMETHEUS_TIMEOUT", 30*time.Second),
+			TemplatesFile:      expandTilde(getEnv("KZ_PROMETHEUS_TEMPLATES_FILE", "")),
 		},
 	}
 }
@@ -124,17 +291,50 @@ func getBool(key string, fallback bool) bool {
 }
 
 func splitCSV(value string) []string {
-	parts := strings.Split(value, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed == "" {
+	result := splitList(value)
+	if len(result) == 0 {
+		return []string{"*"}
+	}
+	return result
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// splitMapping parses "key=value;key=value" pairs. The value is taken after the
+// last '=' so keys may be distinguished names such as "CN=admins,DC=corp=admin".
+func splitMapping(value string) map[string]string {
+	result := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		idx := strings.LastIndex(part, "=")
+		if idx <= 0 || idx == len(part)-1 {
 			continue
 		}
-		result = append(result, trimmed)
+		result[strings.TrimSpace(part[:idx])] = strings.TrimSpace(part[idx+1:])
 	}
-	if len(result) == 0 {
-		return []string{"*"}
+	return result
+}
+
+// splitURLMapping parses "name=url;name=url". Unlike splitMapping it splits
+// on the first '=' since URLs may contain '=' in their query.
+func splitURLMapping(value string) map[string]string {
+	result := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		key, url, ok := strings.Cut(strings.TrimSpace(part), "=")
+		key, url = strings.TrimSpace(key), strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if !ok || key == "" || url == "" {
+			continue
+		}
+		result[key] = url
 	}
 	return result
 }
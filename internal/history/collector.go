@@ -0,0 +1,97 @@
+// Package history records pod and node usage over time so small clusters get
+// charts without running Prometheus.
+package history
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"kubezen/internal/config"
+	"kubezen/internal/k8s"
+	"kubezen/internal/store"
+)
+
+// Collector periodically samples usage from every synced cluster into the
+// store and compacts old samples.
+type Collector struct {
+	registry *k8s.Registry
+	store    *store.Store
+	cfg      config.MetricsConfig
+	logger   *slog.Logger
+}
+
+func NewCollector(registry *k8s.Registry, store *store.Store, cfg config.MetricsConfig, logger *slog.Logger) *Collector {
+	return &Collector{registry: registry, store: store, cfg: cfg, logger: logger}
+}
+
+// Run samples every HistoryInterval and compacts every HistoryRollup until
+// ctx is done.
+func (c *Collector) Run(ctx context.Context) {
+	if c.cfg.HistoryInterval <= 0 {
+		return
+	}
+	sample := time.NewTicker(c.cfg.HistoryInterval)
+	defer sample.Stop()
+	compactEvery := c.cfg.HistoryRollup
+	if compactEvery <= 0 {
+		compactEvery = c.cfg.HistoryInterval
+	}
+	compact := time.NewTicker(compactEvery)
+	defer compact.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-sample.C:
+			c.Collect(ctx, now)
+		case now := <-compact.C:
+			if err := c.store.CompactMetricSamples(now, c.cfg.HistoryRollup, c.cfg.HistoryRawRetention, c.cfg.HistoryRetention); err != nil {
+				c.logger.Warn("metrics history compaction failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Collect takes one sample from each cluster whose cache is synced. Clusters
+// that were never used aren't started just for sampling.
+func (c *Collector) Collect(ctx context.Context, now time.Time) {
+	for _, info := range c.registry.List() {
+		if !info.Synced {
+			continue
+		}
+		svc, err := c.registry.Service(ctx, info.Name)
+		if err != nil {
+			continue
+		}
+		usage, err := svc.UsageSnapshot(ctx)
+		if err != nil {
+			if !errors.Is(err, k8s.ErrMetricsUnavailable) {
+				c.logger.Warn("metrics history sample failed", slog.String("cluster", info.Name), slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		samples := make([]store.MetricSample, 0, len(usage))
+		for _, u := range usage {
+			ts := u.Timestamp
+			if ts.IsZero() {
+				ts = now
+			}
+			samples = append(samples, store.MetricSample{
+				Cluster:       info.Name,
+				Kind:          u.Kind,
+				Namespace:     u.Namespace,
+				Name:          u.Name,
+				Timestamp:     ts,
+				CPUMillicores: u.CPUMillicores,
+				MemoryBytes:   u.MemoryBytes,
+			})
+		}
+		if err := c.store.InsertMetricSamples(samples); err != nil {
+			c.logger.Warn("metrics history write failed", slog.String("cluster", info.Name), slog.String("error", err.Error()))
+		}
+	}
+}
@@ -8,97 +8,210 @@ import (
 	"path/filepath"
 	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	"kubezen/internal/config"
 )
 
-// Cluster wires the Kubernetes clientset with informer factories.
+const accessReviewTimeout = 10 * time.Second
+
+// Cluster wires the Kubernetes clientset with on-demand informers.
 type Cluster struct {
-	Client  kubernetes.Interface
-	Factory informers.SharedInformerFactory
+	Client     kubernetes.Interface
+	RestConfig *rest.Config
+	// Metrics talks to metrics.k8s.io; requests fail if metrics-server
+	// isn't installed.
+	Metrics metricsclient.Interface
+	// Namespaces switches to namespace-scoped mode: namespaced resources
+	// are cached per listed namespace, and cluster-scoped ones only if the
+	// credentials may list them.
+	Namespaces []string
+	// InformerSyncTimeout bounds how long a request waits for a resource
+	// type's informers to sync after starting them.
+	InformerSyncTimeout time.Duration
+	// InformerIdleTimeout stops a resource type's informers once unused for
+	// this long. Zero keeps them running.
+	InformerIdleTimeout time.Duration
+	// Trim selects what informers drop from cached objects.
+	Trim CacheTrim
+
+	pool     *informerPool // set by start
+	activity *activityTracker
+	contact  *apiContact
 }
 
+// preloadResources are started with the cluster since nearly every view
+// needs them; everything else starts on first use.
+var preloadResources = []string{"pods", "namespaces"}
+
 func NewCluster(cfg config.KubeConfig) (*Cluster, error) {
 	restConfig, err := buildRestConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("create rest config: %w", err)
 	}
+	return newClusterForConfig(restConfig, cfg)
+}
+
+// NewClusterFromKubeconfig builds a cluster from raw kubeconfig content, e.g.
+// a cluster registered through the API.
+func NewClusterFromKubeconfig(raw []byte, contextName string, cfg config.KubeConfig) (*Cluster, error) {
+	restConfig, err := restConfigFromBytes(raw, contextName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newClusterForConfig(restConfig, cfg)
+}
+
+func newClusterForConfig(restConfig *rest.Config, cfg config.KubeConfig) (*Cluster, error) {
+	activity := newActivityTracker()
+	contact := &apiContact{activity: activity}
+	restConfig.Wrap(contact.wrap)
 
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, fmt.Errorf("create clientset: %w", err)
 	}
 
-	factory := newInformerFactory(clientset)
+	metrics, err := metricsclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create metrics client: %w", err)
+	}
 
 	return &Cluster{
-		Client:  clientset,
-		Factory: factory,
+		Client:              clientset,
+		RestConfig:          restConfig,
+		Metrics:             metrics,
+		Namespaces:          cfg.Namespaces,
+		InformerSyncTimeout: cfg.InformerSyncTimeout,
+		InformerIdleTimeout: cfg.InformerIdleTimeout,
+		Trim: CacheTrim{
+			LastApplied: cfg.TrimLastApplied,
+			EnvOver:     cfg.TrimEnvOver,
+			SecretData:  cfg.TrimSecretData,
+		},
+		activity: activity,
+		contact:  contact,
 	}, nil
 }
 
-// Start runs the informer factory and waits for caches to sync.
+// Start runs the preloaded informers and waits for their caches to sync.
 func (c *Cluster) Start(ctx context.Context) error {
-	if c == nil || c.Factory == nil {
-		return errors.New("nil informer factory")
+	return c.start(ctx, ctx.Done())
+}
+
+// start sets up the informer pool, running informers until ctx is done, and
+// waits for the preloaded caches to sync until waitStop is closed.
+func (c *Cluster) start(ctx context.Context, waitStop <-chan struct{}) error {
+	if c == nil || c.Client == nil {
+		return errors.New("nil kubernetes client")
+	}
+	if c.activity == nil {
+		c.activity = newActivityTracker()
+	}
+	c.pool = &informerPool{
+		ctx:         ctx,
+		client:      c.Client,
+		namespaces:  c.Namespaces,
+		syncTimeout: c.InformerSyncTimeout,
+		idleTimeout: c.InformerIdleTimeout,
+		trim:        c.Trim,
+		activity:    c.activity,
+		allowed:     c.canListWatch,
+		running:     make(map[string]*pooledInformer),
+		access:      make(map[string]bool),
 	}
+	go c.pool.runJanitor()
 
-	// Lazily instantiate informers before starting the factory; anything created
-	// after Start won't run automatically.
-	pods := c.Factory.Core().V1().Pods().Informer()
-	nodes := c.Factory.Core().V1().Nodes().Informer()
-	deployments := c.Factory.Apps().V1().Deployments().Informer()
-	namespaces := c.Factory.Core().V1().Namespaces().Informer()
-	events := c.Factory.Core().V1().Events().Informer()
+	for _, resource := range preloadResources {
+		err := c.pool.warm(resource, waitStop)
+		if errors.Is(err, ErrCacheWarming) {
+			return errors.New("failed to sync informers before shutdown")
+		}
+		if err != nil && !errors.Is(err, ErrNotPermitted) {
+			return err
+		}
+	}
+	return nil
+}
 
-	c.Factory.Start(ctx.Done())
+// canListWatch asks the API server whether the credentials may list and
+// watch a core cluster-scoped resource.
+func (c *Cluster) canListWatch(ctx context.Context, resource string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, accessReviewTimeout)
+	defer cancel()
+	for _, verb := range []string{"list", "watch"} {
+		review, err := c.Client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: verb, Resource: resource},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		if !review.Status.Allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
-	syncFuncs := []cache.InformerSynced{
-		pods.HasSynced,
-		nodes.HasSynced,
-		deployments.HasSynced,
-		namespaces.HasSynced,
-		events.HasSynced,
+func buildRestConfig(cfg config.KubeConfig) (*rest.Config, error) {
+	if path := KubeconfigPath(cfg); path != "" {
+		return restConfigFromKubeconfig(path, cfg)
 	}
 
-	if ok := cache.WaitForCacheSync(ctx.Done(), syncFuncs...); !ok {
-		return errors.New("failed to sync informers before shutdown")
+	// Fallback to in-cluster config
+	inCluster, err := rest.InClusterConfig()
+	if err == nil {
+		applyClientTunables(inCluster, cfg)
+		return inCluster, nil
 	}
-	return nil
+
+	return nil, fmt.Errorf("unable to load kubeconfig or in-cluster config: %w", err)
 }
 
-func buildRestConfig(cfg config.KubeConfig) (*rest.Config, error) {
+// KubeconfigPath resolves the kubeconfig file to use: the configured path,
+// then $KUBECONFIG, then ~/.kube/config. Empty means in-cluster config.
+func KubeconfigPath(cfg config.KubeConfig) string {
 	if cfg.KubeconfigPath != "" {
-		return restConfigFromKubeconfig(cfg.KubeconfigPath, cfg)
+		return cfg.KubeconfigPath
 	}
 
 	// Try env KUBECONFIG if present
 	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
-		return restConfigFromKubeconfig(kubeconfig, cfg)
+		return kubeconfig
 	}
 
 	// Try default home kubeconfig
 	if home := homedir.HomeDir(); home != "" {
 		path := filepath.Join(home, ".kube", "config")
 		if _, err := os.Stat(path); err == nil {
-			return restConfigFromKubeconfig(path, cfg)
+			return path
 		}
 	}
+	return ""
+}
 
-	// Fallback to in-cluster config
-	inCluster, err := rest.InClusterConfig()
-	if err == nil {
-		applyClientTunables(inCluster, cfg)
-		return inCluster, nil
+func restConfigFromBytes(raw []byte, contextName string, cfg config.KubeConfig) (*rest.Config, error) {
+	clientConfig, err := clientcmd.Load(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse kubeconfig: %w", err)
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*clientConfig, contextName, overrides, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
 	}
 
-	return nil, fmt.Errorf("unable to load kubeconfig or in-cluster config: %w", err)
+	applyClientTunables(restConfig, cfg)
+	return restConfig, nil
 }
 
 func restConfigFromKubeconfig(path string, cfg config.KubeConfig) (*rest.Config, error) {
@@ -118,11 +231,11 @@ func restConfigFromKubeconfig(path string, cfg config.KubeConfig) (*rest.Config,
 	return restConfig, nil
 }
 
-func newInformerFactory(client kubernetes.Interface) informers.SharedInformerFactory {
+func newInformerFactory(client kubernetes.Interface, trim CacheTrim, options ...informers.SharedInformerOption) informers.SharedInformerFactory {
 	return informers.NewSharedInformerFactoryWithOptions(
 		client,
 		30*time.Second,
-		informers.WithTransform(stripHeavyFields),
+		append([]informers.SharedInformerOption{informers.WithTransform(trim.transform)}, options...)...,
 	)
 }
 
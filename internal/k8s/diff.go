@@ -0,0 +1,295 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindConfigMap   = "ConfigMap"
+
+	DiffMissingInSource = "missingInSource"
+	DiffMissingInTarget = "missingInTarget"
+	DiffChanged         = "changed"
+
+	// Long ConfigMap values are cut in diff output.
+	maxDiffValueLength = 256
+)
+
+// DiffKinds are the kinds a workload diff can compare.
+var DiffKinds = []string{KindDeployment, KindStatefulSet, KindConfigMap}
+
+// WorkloadSnapshot is the comparable subset of a namespace's workloads.
+type WorkloadSnapshot struct {
+	objects map[string]workloadSpec // keyed by kind/name
+}
+
+type workloadSpec struct {
+	kind       string
+	name       string
+	replicas   *int32
+	containers map[string]containerSpec
+	data       map[string]string
+}
+
+type containerSpec struct {
+	image    string
+	env      map[string]string
+	requests map[string]string
+}
+
+// WorkloadSnapshot captures the given kinds in namespace. Deployments come
+// from the informer cache; StatefulSets and ConfigMaps aren't cached and are
+// listed from the API server.
+func (s *Service) WorkloadSnapshot(ctx context.Context, namespace string, kinds []string) (*WorkloadSnapshot, error) {
+	if namespace == "" || namespace == "all" {
+		return nil, fmt.Errorf("namespace required")
+	}
+	snapshot := &WorkloadSnapshot{objects: make(map[string]workloadSpec)}
+	for _, kind := range kinds {
+		switch kind {
+		case KindDeployment:
+			lister, err := s.deploymentLister(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("list deployments: %w", err)
+			}
+			deployments, err := lister.Deployments(namespace).List(labels.Everything())
+			if err != nil {
+				return nil, fmt.Errorf("list deployments: %w", err)
+			}
+			for _, deploy := range deployments {
+				snapshot.add(workloadSpec{
+					kind:       KindDeployment,
+					name:       deploy.Name,
+					replicas:   deploy.Spec.Replicas,
+					containers: containerSpecs(deploy.Spec.Template.Spec),
+				})
+			}
+		case KindStatefulSet:
+			list, err := s.client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("list statefulsets: %w", err)
+			}
+			for i := range list.Items {
+				sts := &list.Items[i]
+				snapshot.add(statefulSetSpec(sts))
+			}
+		case KindConfigMap:
+			list, err := s.client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("list configmaps: %w", err)
+			}
+			for i := range list.Items {
+				cm := &list.Items[i]
+				snapshot.add(configMapSpec(cm))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported kind %q", kind)
+		}
+	}
+	return snapshot, nil
+}
+
+// DiffWorkloads compares two snapshots. Objects present on both sides without
+// differences are left out.
+func DiffWorkloads(source, target *WorkloadSnapshot) []ObjectDiff {
+	keys := make(map[string]struct{})
+	for key := range source.objects {
+		keys[key] = struct{}{}
+	}
+	for key := range target.objects {
+		keys[key] = struct{}{}
+	}
+
+	out := make([]ObjectDiff, 0)
+	for key := range keys {
+		src, inSource := source.objects[key]
+		dst, inTarget := target.objects[key]
+		switch {
+		case !inSource:
+			out = append(out, ObjectDiff{Kind: dst.kind, Name: dst.name, Status: DiffMissingInSource})
+		case !inTarget:
+			out = append(out, ObjectDiff{Kind: src.kind, Name: src.name, Status: DiffMissingInTarget})
+		default:
+			if fields := diffSpecs(src, dst); len(fields) > 0 {
+				out = append(out, ObjectDiff{Kind: src.kind, Name: src.name, Status: DiffChanged, Differences: fields})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (w *WorkloadSnapshot) add(spec workloadSpec) {
+	w.objects[spec.kind+"/"+spec.name] = spec
+}
+
+func diffSpecs(src, dst workloadSpec) []FieldDiff {
+	var out []FieldDiff
+	if src.kind != KindConfigMap {
+		if a, b := replicaString(src.replicas), replicaString(dst.replicas); a != b {
+			out = append(out, FieldDiff{Field: "replicas", Source: a, Target: b})
+		}
+	}
+
+	for _, name := range unionKeys(src.containers, dst.containers) {
+		a, inSource := src.containers[name]
+		b, inTarget := dst.containers[name]
+		prefix := "containers[" + name + "]"
+		switch {
+		case !inSource:
+			out = append(out, FieldDiff{Field: prefix, Target: b.image})
+			continue
+		case !inTarget:
+			out = append(out, FieldDiff{Field: prefix, Source: a.image})
+			continue
+		}
+		if a.image != b.image {
+			out = append(out, FieldDiff{Field: prefix + ".image", Source: a.image, Target: b.image})
+		}
+		out = append(out, diffMaps(prefix+".env.", a.env, b.env)...)
+		out = append(out, diffMaps(prefix+".resources.requests.", a.requests, b.requests)...)
+	}
+
+	out = append(out, diffMaps("data.", src.data, dst.data)...)
+	return out
+}
+
+func diffMaps(prefix string, a, b map[string]string) []FieldDiff {
+	var out []FieldDiff
+	for _, key := range unionKeys(a, b) {
+		va, inA := a[key]
+		vb, inB := b[key]
+		if inA && inB && va == vb {
+			continue
+		}
+		out = append(out, FieldDiff{
+			Field:  prefix + key,
+			Source: truncateValue(va),
+			Target: truncateValue(vb),
+		})
+	}
+	return out
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		seen[k] = struct{}{}
+	}
+	for k := range b {
+		seen[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func statefulSetSpec(sts *appsv1.StatefulSet) workloadSpec {
+	return workloadSpec{
+		kind:       KindStatefulSet,
+		name:       sts.Name,
+		replicas:   sts.Spec.Replicas,
+		containers: containerSpecs(sts.Spec.Template.Spec),
+	}
+}
+
+func configMapSpec(cm *corev1.ConfigMap) workloadSpec {
+	data := make(map[string]string, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		data[k] = v
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = fmt.Sprintf("<%d bytes binary>", len(v))
+	}
+	return workloadSpec{kind: KindConfigMap, name: cm.Name, data: data}
+}
+
+func containerSpecs(spec corev1.PodSpec) map[string]containerSpec {
+	out := make(map[string]containerSpec, len(spec.Containers))
+	for _, c := range spec.Containers {
+		env := make(map[string]string, len(c.Env))
+		for _, e := range c.Env {
+			env[e.Name] = envValue(e)
+		}
+		out[c.Name] = containerSpec{
+			image:    c.Image,
+			env:      env,
+			requests: quantityMap(c.Resources.Requests),
+		}
+	}
+	return out
+}
+
+// envValue renders references instead of resolving them so secrets never end
+// up in a diff.
+func envValue(e corev1.EnvVar) string {
+	if e.ValueFrom == nil {
+		return e.Value
+	}
+	switch {
+	case e.ValueFrom.SecretKeyRef != nil:
+		return "secret:" + e.ValueFrom.SecretKeyRef.Name + "/" + e.ValueFrom.SecretKeyRef.Key
+	case e.ValueFrom.ConfigMapKeyRef != nil:
+		return "configmap:" + e.ValueFrom.ConfigMapKeyRef.Name + "/" + e.ValueFrom.ConfigMapKeyRef.Key
+	case e.ValueFrom.FieldRef != nil:
+		return "field:" + e.ValueFrom.FieldRef.FieldPath
+	case e.ValueFrom.ResourceFieldRef != nil:
+		return "resource:" + e.ValueFrom.ResourceFieldRef.Resource
+	}
+	return ""
+}
+
+func replicaString(replicas *int32) string {
+	if replicas == nil {
+		return "1" // API default
+	}
+	return fmt.Sprintf("%d", *replicas)
+}
+
+func truncateValue(v string) string {
+	if len(v) <= maxDiffValueLength {
+		return v
+	}
+	return v[:maxDiffValueLength] + "..."
+}
+
+// ParseDiffKinds maps a comma separated kinds query (e.g.
+// "deployments,configmaps") to kind names. Empty means all kinds.
+func ParseDiffKinds(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return DiffKinds, nil
+	}
+	var kinds []string
+	for _, part := range strings.Split(raw, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "deployment", "deployments", "deploy":
+			kinds = append(kinds, KindDeployment)
+		case "statefulset", "statefulsets", "sts":
+			kinds = append(kinds, KindStatefulSet)
+		case "configmap", "configmaps", "cm":
+			kinds = append(kinds, KindConfigMap)
+		case "":
+		default:
+			return nil, fmt.Errorf("unsupported kind %q", strings.TrimSpace(part))
+		}
+	}
+	return kinds, nil
+}
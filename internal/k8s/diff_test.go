@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiffWorkloads(t *testing.T) {
+	deployment := func(ns, image string, replicas int32, cpu, logLevel string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: ns},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  "api",
+					Image: image,
+					Env:   []corev1.EnvVar{{Name: "LOG_LEVEL", Value: logLevel}},
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse(cpu),
+					}},
+				}}}},
+			},
+		}
+	}
+
+	staging := newTestService(t, nil, nil, []*appsv1.Deployment{deployment("app", "api:1.3", 1, "100m", "debug")}, nil)
+	staging.client = fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "app"},
+		Data:       map[string]string{"feature": "on"},
+	})
+	prod := newTestService(t, nil, nil, []*appsv1.Deployment{deployment("app", "api:1.2", 3, "100m", "info")}, nil)
+
+	kinds := []string{KindDeployment, KindConfigMap}
+	source, err := staging.WorkloadSnapshot(context.Background(), "app", kinds)
+	if err != nil {
+		t.Fatalf("source snapshot: %v", err)
+	}
+	target, err := prod.WorkloadSnapshot(context.Background(), "app", kinds)
+	if err != nil {
+		t.Fatalf("target snapshot: %v", err)
+	}
+
+	diffs := DiffWorkloads(source, target)
+	if len(diffs) != 2 {
+		t.Fatalf("expected 2 diffs, got %+v", diffs)
+	}
+	if diffs[0].Kind != KindConfigMap || diffs[0].Status != DiffMissingInTarget {
+		t.Fatalf("expected missing configmap, got %+v", diffs[0])
+	}
+
+	fields := map[string]FieldDiff{}
+	for _, f := range diffs[1].Differences {
+		fields[f.Field] = f
+	}
+	if len(fields) != 3 {
+		t.Fatalf("expected replicas, image and env diffs, got %+v", diffs[1].Differences)
+	}
+	if f := fields["containers[api].image"]; f.Source != "api:1.3" || f.Target != "api:1.2" {
+		t.Fatalf("unexpected image diff %+v", f)
+	}
+	if f := fields["replicas"]; f.Source != "1" || f.Target != "3" {
+		t.Fatalf("unexpected replicas diff %+v", f)
+	}
+	if _, ok := fields["containers[api].env.LOG_LEVEL"]; !ok {
+		t.Fatalf("missing env diff")
+	}
+}
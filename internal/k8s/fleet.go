@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FleetPods lists pods matching opts across the named clusters. Clusters that
+// can't be reached within the fleet timeout are reported in the returned
+// errors instead of failing the whole view. Sorting and paging apply to the
+// merged result; only an invalid query fails.
+func (r *Registry) FleetPods(ctx context.Context, clusters []string, opts ListOptions) (FleetResponse[PodSummary], error) {
+	if _, _, err := listSelectors(opts, podFields(&corev1.Pod{})); err != nil {
+		return FleetResponse[PodSummary]{}, err
+	}
+	items, errs := fanOut(ctx, r, clusters, func(ctx context.Context, cluster string, svc *Service) ([]PodSummary, error) {
+		pods, err := svc.ListPods(ctx, opts)
+		for i := range pods {
+			pods[i].Cluster = cluster
+		}
+		return pods, err
+	})
+	return fleetPage(items, errs, opts)
+}
+
+// FleetNodes lists nodes across the named clusters, see FleetPods.
+func (r *Registry) FleetNodes(ctx context.Context, clusters []string, opts ListOptions) (FleetResponse[NodeSummary], error) {
+	if _, _, err := listSelectors(opts, nodeFields(&corev1.Node{})); err != nil {
+		return FleetResponse[NodeSummary]{}, err
+	}
+	items, errs := fanOut(ctx, r, clusters, func(ctx context.Context, cluster string, svc *Service) ([]NodeSummary, error) {
+		nodes, err := svc.ListNodes(ctx, opts)
+		for i := range nodes {
+			nodes[i].Cluster = cluster
+		}
+		return nodes, err
+	})
+	return fleetPage(items, errs, opts)
+}
+
+// fanOut runs list concurrently against every cluster, each bounded by the
+// configured fleet timeout, and merges the results.
+func fanOut[T any](ctx context.Context, r *Registry, clusters []string, list func(context.Context, string, *Service) ([]T, error)) ([]T, []ClusterError) {
+	type result struct {
+		cluster string
+		items   []T
+		err     error
+	}
+
+	results := make([]result, len(clusters))
+	var wg sync.WaitGroup
+	for i, name := range clusters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clusterCtx := ctx
+			if r.cfg.FleetTimeout > 0 {
+				var cancel context.CancelFunc
+				clusterCtx, cancel = context.WithTimeout(ctx, r.cfg.FleetTimeout)
+				defer cancel()
+			}
+			res := result{cluster: name}
+			svc, err := r.Service(clusterCtx, name)
+			if err == nil {
+				res.items, err = list(clusterCtx, name, svc)
+			}
+			res.err = err
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	var items []T
+	var errs []ClusterError
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, ClusterError{Cluster: res.cluster, Error: res.err.Error()})
+			continue
+		}
+		items = append(items, res.items...)
+	}
+	return items, errs
+}
+
+func fleetPage[T any](items []T, errs []ClusterError, opts ListOptions) (FleetResponse[T], error) {
+	page, next, err := pageItems(items, opts)
+	if err != nil {
+		return FleetResponse[T]{}, err
+	}
+	return FleetResponse[T]{
+		Items:    page,
+		Count:    len(items),
+		Continue: next,
+		Errors:   errs,
+	}, nil
+}
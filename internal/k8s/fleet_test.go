@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFleetPodsMergesClustersAndReportsErrors(t *testing.T) {
+	failed := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		}
+	}
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ok", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	registry := newTestRegistry(t, map[string]*fake.Clientset{
+		"dev":  fake.NewSimpleClientset(failed("dev-a"), running),
+		"prod": fake.NewSimpleClientset(failed("prod-a"), failed("prod-b")),
+	})
+	registry.clusters["broken"] = &registryEntry{
+		name:   "broken",
+		source: ClusterSourceAPI,
+		build:  func() (*Cluster, error) { return nil, errors.New("unreachable") },
+	}
+
+	resp, err := registry.FleetPods(context.Background(), []string{"broken", "dev", "prod"}, ListOptions{Status: "failed", Limit: 2})
+	if err != nil {
+		t.Fatalf("fleet pods: %v", err)
+	}
+	if resp.Count != 3 || len(resp.Items) != 2 {
+		t.Fatalf("expected 3 total / 2 on page, got %d / %d", resp.Count, len(resp.Items))
+	}
+	if resp.Items[0].Cluster != "dev" || resp.Items[1].Cluster != "prod" || resp.Items[1].Name != "prod-a" {
+		t.Fatalf("unexpected order: %+v", resp.Items)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Cluster != "broken" {
+		t.Fatalf("expected error for broken cluster, got %+v", resp.Errors)
+	}
+}
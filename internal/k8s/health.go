@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// contactFresh is how recent a successful API server response must be
+	// for the health check to skip probing.
+	contactFresh = 15 * time.Second
+	pingTimeout  = 3 * time.Second
+)
+
+// InformerHealth reports whether one informer completed its initial list.
+type InformerHealth struct {
+	Resource string `json:"resource"`
+	Synced   bool   `json:"synced"`
+}
+
+// ClusterHealth describes a started cluster for health checks.
+type ClusterHealth struct {
+	Cluster     string           `json:"cluster"`
+	Default     bool             `json:"default"`
+	Synced      bool             `json:"synced"`
+	Informers   []InformerHealth `json:"informers,omitempty"`
+	Reachable   bool             `json:"reachable"`
+	LastContact *time.Time       `json:"lastContact,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Healthy reports whether every informer synced and the API server answered.
+func (h ClusterHealth) Healthy() bool {
+	return h.Synced && h.Reachable && h.Error == ""
+}
+
+// apiContact records the last time the API server answered any request, and
+// which informer watches it accepted.
+type apiContact struct {
+	last     atomic.Int64 // unix nanoseconds
+	activity *activityTracker
+}
+
+func (a *apiContact) wrap(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		// Any response short of a server error proves the API server is up,
+		// including 403s for resources we may not list.
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			a.last.Store(time.Now().UnixNano())
+		}
+		// Reflectors re-establish their watch every 5-10 minutes, so an
+		// accepted watch request is a heartbeat even for quiet resources.
+		if err == nil && resp.StatusCode == http.StatusOK && a.activity != nil {
+			resource := path.Base(req.URL.Path)
+			if isWatch(req) {
+				a.activity.get(resource).watched()
+			} else if activity, ok := a.activity.lookup(resource); ok && req.Method == http.MethodGet {
+				// Reflectors list on start and whenever their watch expired.
+				activity.listed()
+			}
+		}
+		return resp, err
+	})
+}
+
+func (a *apiContact) time() time.Time {
+	return unixTime(a.last.Load())
+}
+
+func isWatch(req *http.Request) bool {
+	watch := req.URL.Query().Get("watch")
+	return watch == "true" || watch == "1"
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// LastContact is when the API server last answered a request, zero if never.
+func (c *Cluster) LastContact() time.Time {
+	if c.contact == nil {
+		return time.Time{}
+	}
+	return c.contact.time()
+}
+
+// Ping asks the API server for its version.
+func (c *Cluster) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return c.Client.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// Health checks every cluster whose informers were started. The API server is
+// only probed when nothing was heard from it recently, so frequent probes
+// don't add load.
+func (r *Registry) Health(ctx context.Context) []ClusterHealth {
+	r.mu.RLock()
+	entries := make([]*registryEntry, 0, len(r.clusters))
+	for _, entry := range r.clusters {
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	var (
+		mu  sync.Mutex
+		out []ClusterHealth
+		wg  sync.WaitGroup
+	)
+	for _, entry := range entries {
+		entry.mu.Lock()
+		started := entry.ready != nil
+		cluster, synced, err := entry.cluster, entry.synced, entry.err
+		entry.mu.Unlock()
+		if !started {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			health := ClusterHealth{Cluster: entry.name, Default: entry.name == r.defaultName, Synced: synced}
+			if err != nil {
+				health.Error = err.Error()
+			}
+			if cluster != nil {
+				health.checkCluster(ctx, cluster)
+			} else if err == nil {
+				health.Error = ErrCacheWarming.Error()
+			}
+			mu.Lock()
+			out = append(out, health)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(out, func(i, j int) bool { return out[i].Cluster < out[j].Cluster })
+	return out
+}
+
+func (h *ClusterHealth) checkCluster(ctx context.Context, cluster *Cluster) {
+	if h.Synced {
+		for _, stat := range cluster.InformerStats() {
+			h.Informers = append(h.Informers, InformerHealth{Resource: stat.Resource, Synced: stat.Synced})
+			if !stat.Synced {
+				h.Synced = false
+			}
+		}
+	}
+
+	if time.Since(cluster.LastContact()) < contactFresh {
+		h.Reachable = true
+	} else if err := cluster.Ping(ctx); err != nil {
+		if h.Error == "" {
+			h.Error = fmt.Sprintf("api server: %v", err)
+		}
+	} else {
+		h.Reachable = true
+	}
+	if last := cluster.LastContact(); !last.IsZero() {
+		h.LastContact = &last
+	}
+}
@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAPIContactIgnoresServerErrors(t *testing.T) {
+	var contact apiContact
+	status := http.StatusInternalServerError
+	rt := contact.wrap(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status}, nil
+	}))
+	req, _ := http.NewRequest(http.MethodGet, "https://example.invalid/version", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if !contact.time().IsZero() {
+		t.Fatal("server error counted as contact")
+	}
+
+	status = http.StatusForbidden
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if contact.time().IsZero() {
+		t.Fatal("forbidden response not counted as contact")
+	}
+}
+
+func TestAPIContactTracksWatchRenewals(t *testing.T) {
+	activity := newActivityTracker()
+	contact := apiContact{activity: activity}
+	rt := contact.wrap(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	pods := activity.get("pods")
+	pods.lastWatch.Store(time.Now().Add(-time.Hour).UnixNano())
+	if age := pods.age(time.Now()); age < time.Hour {
+		t.Fatalf("expected stale cache, got age %s", age)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.invalid/api/v1/namespaces/team-a/pods?watch=true", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if age := pods.age(time.Now()); age > time.Minute {
+		t.Fatalf("watch renewal not recorded, age %s", age)
+	}
+	if pods.lastList.Load() != 0 {
+		t.Fatal("watch counted as sync")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://example.invalid/api/v1/namespaces/team-a/pods?limit=500", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if pods.lastList.Load() == 0 {
+		t.Fatal("list not recorded as sync")
+	}
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Informer indexes for relationship lookups, so detail views only touch the
+// matching objects.
+const (
+	indexByNode           = "byNode"           // pods by spec.nodeName
+	indexByOwner          = "byOwner"          // objects by owner UID
+	indexByInvolvedObject = "byInvolvedObject" // events by involved object UID
+)
+
+var (
+	podIndexers        = cache.Indexers{indexByNode: podNodeIndex, indexByOwner: ownerIndex}
+	replicaSetIndexers = cache.Indexers{indexByOwner: ownerIndex}
+	eventIndexers      = cache.Indexers{indexByInvolvedObject: involvedObjectIndex}
+)
+
+func podNodeIndex(obj any) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+func ownerIndex(obj any) ([]string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, nil
+	}
+	refs := accessor.GetOwnerReferences()
+	uids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		uids = append(uids, string(ref.UID))
+	}
+	return uids, nil
+}
+
+func involvedObjectIndex(obj any) ([]string, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.InvolvedObject.UID == "" {
+		return nil, nil
+	}
+	return []string{string(event.InvolvedObject.UID)}, nil
+}
@@ -0,0 +1,82 @@
+package k8s
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodNodeIndex(t *testing.T) {
+	scheduled := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-a"}}
+	if keys, err := podNodeIndex(scheduled); err != nil || !reflect.DeepEqual(keys, []string{"node-a"}) {
+		t.Fatalf("unexpected keys for scheduled pod: %v %v", keys, err)
+	}
+	pending := &corev1.Pod{}
+	if keys, err := podNodeIndex(pending); err != nil || keys != nil {
+		t.Fatalf("expected no keys for unscheduled pod, got %v %v", keys, err)
+	}
+	if keys, err := podNodeIndex(&corev1.Node{}); err != nil || keys != nil {
+		t.Fatalf("expected no keys for non-pod, got %v %v", keys, err)
+	}
+}
+
+func TestOwnerIndex(t *testing.T) {
+	owned := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+		{Kind: "Deployment", Name: "web", UID: "deploy-uid"},
+		{Kind: "Rollout", Name: "web", UID: "rollout-uid"},
+	}}}
+	if keys, err := ownerIndex(owned); err != nil || !reflect.DeepEqual(keys, []string{"deploy-uid", "rollout-uid"}) {
+		t.Fatalf("unexpected keys for owned object: %v %v", keys, err)
+	}
+	if keys, err := ownerIndex(&corev1.Pod{}); err != nil || len(keys) != 0 {
+		t.Fatalf("expected no keys without owners, got %v %v", keys, err)
+	}
+	if keys, err := ownerIndex("not an object"); err != nil || keys != nil {
+		t.Fatalf("expected no keys for non-object, got %v %v", keys, err)
+	}
+}
+
+func TestPodIndexersLookup(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, podIndexers)
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", OwnerReferences: []metav1.OwnerReference{{UID: "rs-1"}, {UID: "job-1"}}}, Spec: corev1.PodSpec{NodeName: "node-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default", OwnerReferences: []metav1.OwnerReference{{UID: "rs-1"}}}, Spec: corev1.PodSpec{NodeName: "node-b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"}},
+	}
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatalf("add %s: %v", pod.Name, err)
+		}
+	}
+
+	lookup := func(index, key string) []string {
+		t.Helper()
+		objs, err := indexer.ByIndex(index, key)
+		if err != nil {
+			t.Fatalf("lookup %s=%s: %v", index, key, err)
+		}
+		names := make([]string, 0, len(objs))
+		for _, obj := range objs {
+			names = append(names, obj.(*corev1.Pod).Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	if got := lookup(indexByNode, "node-a"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("unexpected pods on node-a: %v", got)
+	}
+	if got := lookup(indexByNode, ""); len(got) != 0 {
+		t.Fatalf("unscheduled pods must not be indexed, got %v", got)
+	}
+	if got := lookup(indexByOwner, "rs-1"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected pods owned by rs-1: %v", got)
+	}
+	if got := lookup(indexByOwner, "job-1"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("unexpected pods owned by job-1: %v", got)
+	}
+}
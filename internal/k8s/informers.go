@@ -0,0 +1,244 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrNotPermitted means the cluster's credentials may not list a resource, so
+// KubeZen doesn't cache it.
+var ErrNotPermitted = errors.New("not permitted for this cluster's credentials")
+
+// informerResource is a resource type KubeZen caches.
+type informerResource struct {
+	name       string // plural resource, as in API paths
+	namespaced bool
+	informer   func(informerFactory) cache.SharedIndexInformer
+	indexers   cache.Indexers // added before the informer starts
+}
+
+var informerResources = []informerResource{
+	{name: "pods", namespaced: true, indexers: podIndexers, informer: func(f informerFactory) cache.SharedIndexInformer { return f.Core().V1().Pods().Informer() }},
+	{name: "nodes", informer: func(f informerFactory) cache.SharedIndexInformer { return f.Core().V1().Nodes().Informer() }},
+	{name: "deployments", namespaced: true, informer: func(f informerFactory) cache.SharedIndexInformer { return f.Apps().V1().Deployments().Informer() }},
+	{name: "replicasets", namespaced: true, indexers: replicaSetIndexers, informer: func(f informerFactory) cache.SharedIndexInformer { return f.Apps().V1().ReplicaSets().Informer() }},
+	{name: "namespaces", informer: func(f informerFactory) cache.SharedIndexInformer { return f.Core().V1().Namespaces().Informer() }},
+	{name: "events", namespaced: true, indexers: eventIndexers, informer: func(f informerFactory) cache.SharedIndexInformer { return f.Core().V1().Events().Informer() }},
+}
+
+// informerSet holds the informers caching one resource type: a single
+// cluster-wide informer, or one per watched namespace.
+type informerSet []cache.SharedIndexInformer
+
+// factoryInformers instantiates every resource's informer on factory.
+func factoryInformers(factory informerFactory) map[string]informerSet {
+	out := make(map[string]informerSet, len(informerResources))
+	for _, res := range informerResources {
+		set := informerSet{res.informer(factory)}
+		// Only fails for stopped informers.
+		_ = set.addIndexers(res.indexers)
+		out[res.name] = set
+	}
+	return out
+}
+
+func (s informerSet) HasSynced() bool {
+	for _, informer := range s {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s informerSet) objects() int {
+	n := 0
+	for _, informer := range s {
+		n += len(informer.GetStore().ListKeys())
+	}
+	return n
+}
+
+// bytes estimates the memory held by the cached objects from their protobuf
+// size. Decoded objects take more, so it's for comparing resource types.
+func (s informerSet) bytes() int64 {
+	var n int64
+	for _, informer := range s {
+		for _, obj := range informer.GetStore().List() {
+			if sized, ok := obj.(interface{ Size() int }); ok {
+				n += int64(sized.Size())
+			}
+		}
+	}
+	return n
+}
+
+// indexer returns a read-only view over the set's caches for listers.
+func (s informerSet) indexer() cache.Indexer {
+	if len(s) == 1 {
+		return s[0].GetIndexer()
+	}
+	indexers := make(multiIndexer, 0, len(s))
+	for _, informer := range s {
+		indexers = append(indexers, informer.GetIndexer())
+	}
+	return indexers
+}
+
+// addEventHandler registers handler on every informer and returns a function
+// removing it again.
+func (s informerSet) addEventHandler(handler cache.ResourceEventHandler) (func(), error) {
+	removes := make([]func(), 0, len(s))
+	remove := func() {
+		for _, r := range removes {
+			r()
+		}
+	}
+	for _, informer := range s {
+		reg, err := informer.AddEventHandler(handler)
+		if err != nil {
+			remove()
+			return nil, err
+		}
+		removes = append(removes, func() { _ = informer.RemoveEventHandler(reg) })
+	}
+	return remove, nil
+}
+
+// addIndexers adds the indexes every informer of the set doesn't have yet.
+func (s informerSet) addIndexers(indexers cache.Indexers) error {
+	for _, informer := range s {
+		existing := informer.GetIndexer().GetIndexers()
+		missing := cache.Indexers{}
+		for name, fn := range indexers {
+			if _, ok := existing[name]; !ok {
+				missing[name] = fn
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := informer.AddIndexers(missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s informerSet) setWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	for _, informer := range s {
+		if err := informer.SetWatchErrorHandler(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multiIndexer merges the caches of per-namespace informers. Namespaces don't
+// overlap, so reads just combine the results of every cache.
+type multiIndexer []cache.Indexer
+
+var errReadOnlyIndexer = errors.New("read-only indexer")
+
+func (m multiIndexer) Add(any) error               { return errReadOnlyIndexer }
+func (m multiIndexer) Update(any) error            { return errReadOnlyIndexer }
+func (m multiIndexer) Delete(any) error            { return errReadOnlyIndexer }
+func (m multiIndexer) Replace([]any, string) error { return errReadOnlyIndexer }
+func (m multiIndexer) Resync() error               { return nil }
+
+func (m multiIndexer) AddIndexers(cache.Indexers) error {
+	return fmt.Errorf("add indexers: %w", errReadOnlyIndexer)
+}
+
+func (m multiIndexer) List() []any {
+	var out []any
+	for _, idx := range m {
+		out = append(out, idx.List()...)
+	}
+	return out
+}
+
+func (m multiIndexer) ListKeys() []string {
+	var out []string
+	for _, idx := range m {
+		out = append(out, idx.ListKeys()...)
+	}
+	return out
+}
+
+func (m multiIndexer) Get(obj any) (any, bool, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	return m.GetByKey(key)
+}
+
+func (m multiIndexer) GetByKey(key string) (any, bool, error) {
+	for _, idx := range m {
+		item, exists, err := idx.GetByKey(key)
+		if err != nil || exists {
+			return item, exists, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (m multiIndexer) Index(indexName string, obj any) ([]any, error) {
+	var out []any
+	for _, idx := range m {
+		items, err := idx.Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+func (m multiIndexer) IndexKeys(indexName, indexedValue string) ([]string, error) {
+	var out []string
+	for _, idx := range m {
+		keys, err := idx.IndexKeys(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, keys...)
+	}
+	return out, nil
+}
+
+func (m multiIndexer) ListIndexFuncValues(indexName string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, idx := range m {
+		for _, v := range idx.ListIndexFuncValues(indexName) {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func (m multiIndexer) ByIndex(indexName, indexedValue string) ([]any, error) {
+	var out []any
+	for _, idx := range m {
+		items, err := idx.ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+func (m multiIndexer) GetIndexers() cache.Indexers {
+	if len(m) == 0 {
+		return cache.Indexers{}
+	}
+	return m[0].GetIndexers()
+}
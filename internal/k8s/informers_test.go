@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceScopedCluster(t *testing.T) {
+	pod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	client := fake.NewSimpleClientset(pod("team-a", "api"), pod("team-b", "web"), pod("other", "db"))
+	// Deny cluster-scoped access, like a service account with namespace-level
+	// RBAC only.
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &authorizationv1.SelfSubjectAccessReview{}, nil
+	})
+	cluster := &Cluster{
+		Client:     client,
+		Namespaces: []string{"team-a", "team-b"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := cluster.Start(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	svc := newService(client, cluster.pool, cluster.Namespaces)
+
+	pods, err := svc.ListPods(ctx, ListOptions{})
+	if err != nil || len(pods) != 2 {
+		t.Fatalf("expected pods of both namespaces, got %v %v", pods, err)
+	}
+	if _, err := svc.GetPod(ctx, "team-b", "web"); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	if _, err := svc.ListNodes(ctx, ListOptions{}); !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("expected ErrNotPermitted for nodes, got %v", err)
+	}
+	namespaces, err := svc.ListNamespaces(ctx, ListOptions{})
+	if err != nil || len(namespaces) != 2 || namespaces[0].Name != "team-a" {
+		t.Fatalf("expected configured namespaces, got %v %v", namespaces, err)
+	}
+}
@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ErrUnsafeKubeconfig rejects uploaded kubeconfigs whose credentials would
+// make the server run commands or read its own files.
+var ErrUnsafeKubeconfig = errors.New("kubeconfig must only use inline credentials")
+
+// SanitizeKubeconfig reduces an uploaded kubeconfig to contextName and its
+// cluster and user. Exec plugins, auth providers and file references
+// (client-certificate, client-key, certificate-authority, tokenFile) are
+// rejected; certificates, keys and tokens have to be inlined.
+func SanitizeKubeconfig(raw []byte, contextName string) ([]byte, error) {
+	kubeconfig, err := clientcmd.Load(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse kubeconfig: %w", err)
+	}
+	if _, ok := kubeconfig.Contexts[contextName]; !ok {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+	kubeconfig.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(kubeconfig); err != nil {
+		return nil, fmt.Errorf("parse kubeconfig: %w", err)
+	}
+
+	for name, cluster := range kubeconfig.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("%w: cluster %q references certificate-authority file, use certificate-authority-data", ErrUnsafeKubeconfig, name)
+		}
+	}
+	for name, user := range kubeconfig.AuthInfos {
+		switch {
+		case user.Exec != nil:
+			return nil, fmt.Errorf("%w: user %q uses an exec plugin", ErrUnsafeKubeconfig, name)
+		case user.AuthProvider != nil:
+			return nil, fmt.Errorf("%w: user %q uses an auth provider", ErrUnsafeKubeconfig, name)
+		case user.ClientCertificate != "":
+			return nil, fmt.Errorf("%w: user %q references client-certificate file, use client-certificate-data", ErrUnsafeKubeconfig, name)
+		case user.ClientKey != "":
+			return nil, fmt.Errorf("%w: user %q references client-key file, use client-key-data", ErrUnsafeKubeconfig, name)
+		case user.TokenFile != "":
+			return nil, fmt.Errorf("%w: user %q references tokenFile, use token", ErrUnsafeKubeconfig, name)
+		}
+	}
+	return clientcmd.Write(*kubeconfig)
+}
@@ -0,0 +1,386 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+const (
+	// metrics-server scrapes every 15s by default; caching for that long
+	// keeps list requests from hitting the aggregated API every time.
+	metricsTTL = 15 * time.Second
+	// metricsRetry is how long to wait before asking again once the metrics
+	// API failed, e.g. because metrics-server isn't installed.
+	metricsRetry    = time.Minute
+	metricsDeadline = 5 * time.Second
+)
+
+// ErrMetricsUnavailable means the metrics.k8s.io API can't be reached.
+var ErrMetricsUnavailable = errors.New("metrics unavailable: is metrics-server installed?")
+
+// PodUsage is a pod's current usage summed over its containers. Percentages
+// are only set when the pod declares the corresponding request or limit.
+type PodUsage struct {
+	CPU                  string    `json:"cpu"`
+	Memory               string    `json:"memory"`
+	CPUMillicores        int64     `json:"cpuMillicores"`
+	MemoryBytes          int64     `json:"memoryBytes"`
+	CPURequestPercent    *float64  `json:"cpuRequestPercent,omitempty"`
+	CPULimitPercent      *float64  `json:"cpuLimitPercent,omitempty"`
+	MemoryRequestPercent *float64  `json:"memoryRequestPercent,omitempty"`
+	MemoryLimitPercent   *float64  `json:"memoryLimitPercent,omitempty"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// NodeUsage is a node's current usage relative to its allocatable resources.
+type NodeUsage struct {
+	CPU           string    `json:"cpu"`
+	Memory        string    `json:"memory"`
+	CPUMillicores int64     `json:"cpuMillicores"`
+	MemoryBytes   int64     `json:"memoryBytes"`
+	CPUPercent    *float64  `json:"cpuPercent,omitempty"`
+	MemoryPercent *float64  `json:"memoryPercent,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type usageSample struct {
+	cpu       resource.Quantity
+	memory    resource.Quantity
+	timestamp time.Time
+}
+
+// metricsCache fronts the metrics API with a short-lived snapshot of all pod
+// and node usage, and backs off while the API is unavailable. Refreshes run
+// outside mu, one at a time per kind, so slow metrics API calls don't hold up
+// callers that already have a snapshot.
+type metricsCache struct {
+	client     metricsclient.Interface
+	namespaces []string // list pod metrics per namespace; empty for all
+	now        func() time.Time
+	refresh    singleflight.Group
+
+	mu               sync.Mutex
+	pods             map[string]usageSample // keyed by namespace/name
+	podsFetched      time.Time
+	nodes            map[string]usageSample
+	nodesFetched     time.Time
+	unavailableUntil time.Time
+	lastErr          error
+}
+
+func newMetricsCache(client metricsclient.Interface, namespaces []string) *metricsCache {
+	if client == nil {
+		return nil
+	}
+	return &metricsCache{client: client, namespaces: namespaces, now: time.Now}
+}
+
+func (m *metricsCache) podSamples(ctx context.Context) (map[string]usageSample, error) {
+	if m == nil {
+		return nil, ErrMetricsUnavailable
+	}
+	return m.samples(ctx, "pods", &m.pods, &m.podsFetched, m.fetchPods)
+}
+
+func (m *metricsCache) nodeSamples(ctx context.Context) (map[string]usageSample, error) {
+	if m == nil {
+		return nil, ErrMetricsUnavailable
+	}
+	return m.samples(ctx, "nodes", &m.nodes, &m.nodesFetched, m.fetchNodes)
+}
+
+// samples returns the snapshot guarded by mu at snapshot and fetched,
+// refreshing it once it's older than metricsTTL. A stale snapshot is returned
+// right away while the refresh runs in the background; without one, callers
+// wait for the refresh or until ctx is done.
+func (m *metricsCache) samples(ctx context.Context, kind string, snapshot *map[string]usageSample, fetched *time.Time, fetch func(context.Context) (map[string]usageSample, error)) (map[string]usageSample, error) {
+	m.mu.Lock()
+	now := m.now()
+	current, age := *snapshot, now.Sub(*fetched)
+	unavailable, lastErr := now.Before(m.unavailableUntil), m.lastErr
+	m.mu.Unlock()
+	if current != nil && age < metricsTTL {
+		return current, nil
+	}
+	if unavailable {
+		return nil, lastErr
+	}
+
+	done := m.refresh.DoChan(kind, func() (any, error) {
+		// Shared by every waiting caller, so not bound to this one's ctx.
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), metricsDeadline)
+		defer cancel()
+		samples, err := fetch(fetchCtx)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if err != nil {
+			return nil, m.fail(m.now(), err)
+		}
+		*snapshot, *fetched = samples, now
+		return samples, nil
+	})
+	if current != nil {
+		return current, nil
+	}
+	select {
+	case result := <-done:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(map[string]usageSample), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *metricsCache) fetchPods(ctx context.Context) (map[string]usageSample, error) {
+	namespaces := m.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	var items []metricsv1beta1.PodMetrics
+	for _, namespace := range namespaces {
+		list, err := m.client.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+	}
+	samples := make(map[string]usageSample, len(items))
+	for _, item := range items {
+		var sample usageSample
+		sample.timestamp = item.Timestamp.Time
+		for _, c := range item.Containers {
+			sample.cpu.Add(c.Usage[corev1.ResourceCPU])
+			sample.memory.Add(c.Usage[corev1.ResourceMemory])
+		}
+		samples[item.Namespace+"/"+item.Name] = sample
+	}
+	return samples, nil
+}
+
+func (m *metricsCache) fetchNodes(ctx context.Context) (map[string]usageSample, error) {
+	list, err := m.client.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	samples := make(map[string]usageSample, len(list.Items))
+	for _, item := range list.Items {
+		samples[item.Name] = usageSample{
+			cpu:       item.Usage[corev1.ResourceCPU],
+			memory:    item.Usage[corev1.ResourceMemory],
+			timestamp: item.Timestamp.Time,
+		}
+	}
+	return samples, nil
+}
+
+// fail records a failed refresh; m.mu must be held.
+func (m *metricsCache) fail(now time.Time, err error) error {
+	m.unavailableUntil = now.Add(metricsRetry)
+	m.lastErr = fmt.Errorf("%w (%v)", ErrMetricsUnavailable, err)
+	return m.lastErr
+}
+
+// usageSortFields maps the sort keys of the top views to usage fields.
+var usageSortFields = map[string]string{
+	"cpu":    "usage.cpuMillicores",
+	"memory": "usage.memoryBytes",
+}
+
+// UsageListOptions prepares list options for TopPods and TopNodes: sort=cpu
+// (default) or sort=memory order by usage, highest first unless an order is
+// given. Other sort values address fields like in any list.
+func UsageListOptions(opts ListOptions) ListOptions {
+	sortKey := strings.ToLower(opts.Sort)
+	if sortKey == "" {
+		sortKey = "cpu"
+	}
+	if field, ok := usageSortFields[sortKey]; ok {
+		opts.Sort = field
+		if opts.Order == "" {
+			opts.Order = OrderDesc
+		}
+	}
+	return opts
+}
+
+// TopPods returns the pods matching opts' selectors that have usage. Pass
+// the result and opts through Page to sort and page it, see
+// UsageListOptions.
+func (s *Service) TopPods(ctx context.Context, opts ListOptions) ([]PodSummary, error) {
+	if _, err := s.metrics.podSamples(ctx); err != nil {
+		return nil, err
+	}
+	pods, err := s.ListPods(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]PodSummary, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Usage != nil {
+			out = append(out, pod)
+		}
+	}
+	return out, nil
+}
+
+// TopNodes returns the nodes matching opts' selectors that have usage, see
+// TopPods.
+func (s *Service) TopNodes(ctx context.Context, opts ListOptions) ([]NodeSummary, error) {
+	if _, err := s.nodeLister(ctx); err != nil {
+		return nil, fmt.Errorf("top nodes: %w", err)
+	}
+	if _, err := s.metrics.nodeSamples(ctx); err != nil {
+		return nil, err
+	}
+	nodes, err := s.ListNodes(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]NodeSummary, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Usage != nil {
+			out = append(out, node)
+		}
+	}
+	return out, nil
+}
+
+// podUsageLookup returns a function attaching usage to pods, or a no-op when
+// metrics are unavailable.
+func (s *Service) podUsageLookup(ctx context.Context) func(*corev1.Pod) *PodUsage {
+	samples, err := s.metrics.podSamples(ctx)
+	if err != nil {
+		return func(*corev1.Pod) *PodUsage { return nil }
+	}
+	return func(pod *corev1.Pod) *PodUsage {
+		sample, ok := samples[pod.Namespace+"/"+pod.Name]
+		if !ok {
+			return nil
+		}
+		return toPodUsage(pod, sample)
+	}
+}
+
+func (s *Service) nodeUsageLookup(ctx context.Context) func(*corev1.Node) *NodeUsage {
+	samples, err := s.metrics.nodeSamples(ctx)
+	if err != nil {
+		return func(*corev1.Node) *NodeUsage { return nil }
+	}
+	return func(node *corev1.Node) *NodeUsage {
+		sample, ok := samples[node.Name]
+		if !ok {
+			return nil
+		}
+		return &NodeUsage{
+			CPU:           sample.cpu.String(),
+			Memory:        sample.memory.String(),
+			CPUMillicores: sample.cpu.MilliValue(),
+			MemoryBytes:   sample.memory.Value(),
+			CPUPercent:    percentOf(sample.cpu.MilliValue(), node.Status.Allocatable.Cpu().MilliValue()),
+			MemoryPercent: percentOf(sample.memory.Value(), node.Status.Allocatable.Memory().Value()),
+			Timestamp:     sample.timestamp,
+		}
+	}
+}
+
+func toPodUsage(pod *corev1.Pod, sample usageSample) *PodUsage {
+	var cpuRequest, cpuLimit, memRequest, memLimit resource.Quantity
+	for _, c := range pod.Spec.Containers {
+		cpuRequest.Add(c.Resources.Requests[corev1.ResourceCPU])
+		cpuLimit.Add(c.Resources.Limits[corev1.ResourceCPU])
+		memRequest.Add(c.Resources.Requests[corev1.ResourceMemory])
+		memLimit.Add(c.Resources.Limits[corev1.ResourceMemory])
+	}
+	cpu, mem := sample.cpu.MilliValue(), sample.memory.Value()
+	return &PodUsage{
+		CPU:                  sample.cpu.String(),
+		Memory:               sample.memory.String(),
+		CPUMillicores:        cpu,
+		MemoryBytes:          mem,
+		CPURequestPercent:    percentOf(cpu, cpuRequest.MilliValue()),
+		CPULimitPercent:      percentOf(cpu, cpuLimit.MilliValue()),
+		MemoryRequestPercent: percentOf(mem, memRequest.Value()),
+		MemoryLimitPercent:   percentOf(mem, memLimit.Value()),
+		Timestamp:            sample.timestamp,
+	}
+}
+
+func percentOf(used, total int64) *float64 {
+	if total <= 0 {
+		return nil
+	}
+	pct := math.Round(float64(used)/float64(total)*1000) / 10
+	return &pct
+}
+
+// UsageSample is a point-in-time usage reading of a pod or node.
+type UsageSample struct {
+	Kind          string // "pod" or "node"
+	Namespace     string
+	Name          string
+	CPUMillicores int64
+	MemoryBytes   int64
+	Timestamp     time.Time
+}
+
+// nodesReadable reports whether the credentials may read nodes; only
+// namespace-scoped mode has to ask.
+func (s *Service) nodesReadable(ctx context.Context) bool {
+	if len(s.scope) == 0 {
+		return true
+	}
+	_, err := s.nodeLister(ctx)
+	return err == nil
+}
+
+// UsageSnapshot returns the current usage of every pod and node.
+func (s *Service) UsageSnapshot(ctx context.Context) ([]UsageSample, error) {
+	pods, err := s.metrics.podSamples(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Node metrics are cluster-scoped; skip them when nodes aren't readable.
+	var nodes map[string]usageSample
+	if s.nodesReadable(ctx) {
+		nodes, err = s.metrics.nodeSamples(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out := make([]UsageSample, 0, len(pods)+len(nodes))
+	for key, sample := range pods {
+		namespace, name, _ := strings.Cut(key, "/")
+		out = append(out, UsageSample{
+			Kind:          "pod",
+			Namespace:     namespace,
+			Name:          name,
+			CPUMillicores: sample.cpu.MilliValue(),
+			MemoryBytes:   sample.memory.Value(),
+			Timestamp:     sample.timestamp,
+		})
+	}
+	for name, sample := range nodes {
+		out = append(out, UsageSample{
+			Kind:          "node",
+			Name:          name,
+			CPUMillicores: sample.cpu.MilliValue(),
+			MemoryBytes:   sample.memory.Value(),
+			Timestamp:     sample.timestamp,
+		})
+	}
+	return out, nil
+}
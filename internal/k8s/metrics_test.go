@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func podWithRequests(name, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+	}
+}
+
+func podMetrics(name, cpu, memory string) metricsv1beta1.PodMetrics {
+	return metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Containers: []metricsv1beta1.ContainerMetrics{{
+			Name: "app",
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}},
+	}
+}
+
+func TestPodUsageAndTopPods(t *testing.T) {
+	svc := newTestService(t, []*corev1.Pod{
+		podWithRequests("idle", "100m", "64Mi"),
+		podWithRequests("busy", "200m", "128Mi"),
+		podWithRequests("unsampled", "100m", "64Mi"),
+	}, nil, nil, nil)
+
+	client := metricsfake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{
+			podMetrics("idle", "10m", "32Mi"),
+			podMetrics("busy", "300m", "64Mi"),
+		}}, nil
+	})
+	svc.metrics = newMetricsCache(client, nil)
+
+	opts := UsageListOptions(ListOptions{Namespace: "default", Limit: 1})
+	pods, err := svc.TopPods(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("top pods: %v", err)
+	}
+	first, err := Page(pods, opts)
+	if err != nil || len(first.Items) != 1 || first.Items[0].Name != "busy" || first.Count != 2 {
+		t.Fatalf("unexpected first page: %+v %v", first, err)
+	}
+	opts.Continue = first.Continue
+	second, err := Page(pods, opts)
+	if err != nil || len(second.Items) != 1 || second.Items[0].Name != "idle" || second.Continue != "" {
+		t.Fatalf("unexpected second page: %+v %v", second, err)
+	}
+	usage := first.Items[0].Usage
+	if usage.CPUMillicores != 300 || *usage.CPURequestPercent != 150 || *usage.MemoryRequestPercent != 50 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	if usage.CPULimitPercent != nil {
+		t.Fatalf("expected no limit percentage without limits")
+	}
+}
+
+func TestMetricsDegradeWhenUnavailable(t *testing.T) {
+	svc := newTestService(t, []*corev1.Pod{podWithRequests("web", "100m", "64Mi")}, nil, nil, nil)
+
+	calls := 0
+	client := metricsfake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		return true, nil, errors.New("the server could not find the requested resource")
+	})
+	svc.metrics = newMetricsCache(client, nil)
+
+	pods, err := svc.ListPods(context.Background(), ListOptions{})
+	if err != nil || len(pods) != 1 || pods[0].Usage != nil {
+		t.Fatalf("expected pods without usage, got %+v %v", pods, err)
+	}
+	if _, err := svc.TopPods(context.Background(), ListOptions{}); !errors.Is(err, ErrMetricsUnavailable) {
+		t.Fatalf("expected metrics unavailable, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected metrics API to be skipped while backing off, got %d calls", calls)
+	}
+}
+
+func TestMetricsRefreshServesStaleSnapshot(t *testing.T) {
+	gate := make(chan struct{})
+	calls := 0
+	client := metricsfake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls > 1 {
+			<-gate
+		}
+		return true, &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{podMetrics("web", "10m", "32Mi")}}, nil
+	})
+	cache := newMetricsCache(client, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	if _, err := cache.podSamples(context.Background()); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+
+	// The refresh blocks in the metrics API; readers keep the old snapshot.
+	now = now.Add(2 * metricsTTL)
+	done := make(chan error, 1)
+	go func() {
+		samples, err := cache.podSamples(context.Background())
+		if err == nil && len(samples) != 1 {
+			err = errors.New("stale snapshot missing")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stale read: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reader blocked behind the metrics refresh")
+	}
+	close(gate)
+}
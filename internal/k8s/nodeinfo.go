@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	legacyNodeRoleLabel = "kubernetes.io/role"
+)
+
+// nodeRoles returns the ROLES column of `kubectl get nodes`, taken from the
+// node-role.kubernetes.io/<role> and kubernetes.io/role labels.
+func nodeRoles(node *corev1.Node) []string {
+	seen := make(map[string]bool)
+	for key, value := range node.Labels {
+		switch {
+		case strings.HasPrefix(key, nodeRoleLabelPrefix):
+			if role := strings.TrimPrefix(key, nodeRoleLabelPrefix); role != "" {
+				seen[role] = true
+			}
+		case key == legacyNodeRoleLabel && value != "":
+			seen[value] = true
+		}
+	}
+	roles := make([]string, 0, len(seen))
+	for role := range seen {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func nodeAddress(node *corev1.Node, addrType corev1.NodeAddressType) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == addrType {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+// nodeTopology returns the node's zone and region, falling back to the
+// deprecated failure-domain labels.
+func nodeTopology(node *corev1.Node) (zone, region string) {
+	zone = node.Labels[corev1.LabelTopologyZone]
+	if zone == "" {
+		zone = node.Labels[corev1.LabelFailureDomainBetaZone]
+	}
+	region = node.Labels[corev1.LabelTopologyRegion]
+	if region == "" {
+		region = node.Labels[corev1.LabelFailureDomainBetaRegion]
+	}
+	return zone, region
+}
+
+func toNodeTaints(node *corev1.Node) []NodeTaint {
+	if len(node.Spec.Taints) == 0 {
+		return nil
+	}
+	out := make([]NodeTaint, 0, len(node.Spec.Taints))
+	for _, taint := range node.Spec.Taints {
+		out = append(out, NodeTaint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+	}
+	return out
+}
+
+// nodeAllocation sums the requests of the node's non-terminated pods against
+// its allocatable resources, like the "Allocated resources" section of
+// `kubectl describe node`. It returns nil if pods can't be read.
+func (s *Service) nodeAllocation(ctx context.Context, node *corev1.Node) *NodeAllocation {
+	objs, err := s.byIndex(ctx, "pods", indexByNode, node.Name)
+	if err != nil {
+		return nil
+	}
+	var cpu, memory resource.Quantity
+	pods := 0
+	for _, obj := range objs {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		requests := podRequests(pod)
+		cpu.Add(requests[corev1.ResourceCPU])
+		memory.Add(requests[corev1.ResourceMemory])
+		pods++
+	}
+	allocatableCPU := node.Status.Allocatable.Cpu().MilliValue()
+	allocatableMemory := node.Status.Allocatable.Memory().Value()
+	return &NodeAllocation{
+		Pods:                 pods,
+		CPURequests:          cpu.MilliValue(),
+		CPUAllocatable:       allocatableCPU,
+		CPURequestPercent:    percentOf(cpu.MilliValue(), allocatableCPU),
+		MemoryRequests:       memory.Value(),
+		MemoryAllocatable:    allocatableMemory,
+		MemoryRequestPercent: percentOf(memory.Value(), allocatableMemory),
+	}
+}
+
+// podRequests returns the resources the scheduler reserves for the pod: the
+// larger of its containers' (plus sidecars') requests and those of any init
+// container running alongside earlier sidecars, plus the pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	reqs := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(reqs, container.Resources.Requests)
+	}
+
+	sidecars := corev1.ResourceList{}
+	initMax := corev1.ResourceList{}
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResources(reqs, container.Resources.Requests)
+			addResources(sidecars, container.Resources.Requests)
+			maxResources(initMax, sidecars)
+			continue
+		}
+		running := corev1.ResourceList{}
+		addResources(running, sidecars)
+		addResources(running, container.Resources.Requests)
+		maxResources(initMax, running)
+	}
+	maxResources(reqs, initMax)
+	addResources(reqs, pod.Spec.Overhead)
+	return reqs
+}
+
+func addResources(dst, src corev1.ResourceList) {
+	for name, quantity := range src {
+		if current, ok := dst[name]; ok {
+			current.Add(quantity)
+			dst[name] = current
+		} else {
+			dst[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func maxResources(dst, src corev1.ResourceList) {
+	for name, quantity := range src {
+		if current, ok := dst[name]; !ok || quantity.Cmp(current) > 0 {
+			dst[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeUnreachablePodReason is set by the node lifecycle controller on pods of
+// unreachable nodes.
+const nodeUnreachablePodReason = "NodeLost"
+
+// podDisplayStatus computes the STATUS column of `kubectl get pods`: the
+// phase, overridden by the pod's reason (e.g. Evicted, NodeLost), init
+// container progress (Init:1/3, Init:CrashLoopBackOff), container waiting or
+// terminated reasons (CrashLoopBackOff, OOMKilled) and Terminating.
+func podDisplayStatus(pod *corev1.Pod) string {
+	reason := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		reason = pod.Status.Reason
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Reason == corev1.PodReasonSchedulingGated {
+			reason = corev1.PodReasonSchedulingGated
+		}
+	}
+
+	sidecars := restartableInitContainers(pod)
+	initializing := false
+	for i, container := range pod.Status.InitContainerStatuses {
+		state := container.State
+		switch {
+		case initContainerDone(container, sidecars):
+			continue
+		case state.Terminated != nil:
+			reason = "Init:" + terminatedReason(state.Terminated)
+		case state.Waiting != nil && state.Waiting.Reason != "" && state.Waiting.Reason != "PodInitializing":
+			reason = "Init:" + state.Waiting.Reason
+		default:
+			reason = fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+		}
+		initializing = true
+		break
+	}
+
+	if !initializing || podConditionTrue(pod, corev1.PodInitialized) {
+		hasRunning := false
+		for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
+			state := pod.Status.ContainerStatuses[i].State
+			switch {
+			case state.Waiting != nil && state.Waiting.Reason != "":
+				reason = state.Waiting.Reason
+			case state.Terminated != nil:
+				reason = terminatedReason(state.Terminated)
+			case pod.Status.ContainerStatuses[i].Ready && state.Running != nil:
+				hasRunning = true
+			}
+		}
+		// A completed container next to running ones doesn't complete the pod.
+		if reason == "Completed" && hasRunning {
+			reason = "NotReady"
+			if podConditionTrue(pod, corev1.PodReady) {
+				reason = "Running"
+			}
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		switch {
+		case pod.Status.Reason == nodeUnreachablePodReason:
+			reason = "Unknown"
+		case pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed:
+			reason = "Terminating"
+		}
+	}
+	return reason
+}
+
+func terminatedReason(state *corev1.ContainerStateTerminated) string {
+	switch {
+	case state.Reason != "":
+		return state.Reason
+	case state.Signal != 0:
+		return fmt.Sprintf("Signal:%d", state.Signal)
+	default:
+		return fmt.Sprintf("ExitCode:%d", state.ExitCode)
+	}
+}
+
+// initContainerDone reports whether the init container no longer holds up
+// the pod: it completed, or it is a sidecar that has started.
+func initContainerDone(status corev1.ContainerStatus, sidecars map[string]bool) bool {
+	if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode == 0 {
+		return true
+	}
+	return sidecars[status.Name] && status.Started != nil && *status.Started
+}
+
+// restartableInitContainers returns the names of sidecar containers, init
+// containers that keep running next to the main ones.
+func restartableInitContainers(pod *corev1.Pod) map[string]bool {
+	out := make(map[string]bool)
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			out[container.Name] = true
+		}
+	}
+	return out
+}
+
+func podConditionTrue(pod *corev1.Pod, condType corev1.PodConditionType) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == condType {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podReadyCount is the READY column of `kubectl get pods`, e.g. "2/3";
+// sidecars count as containers.
+func podReadyCount(pod *corev1.Pod) string {
+	sidecars := restartableInitContainers(pod)
+	total := len(pod.Spec.Containers) + len(sidecars)
+	ready := 0
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Ready && container.State.Running != nil {
+			ready++
+		}
+	}
+	for _, container := range pod.Status.InitContainerStatuses {
+		if sidecars[container.Name] && container.Started != nil && *container.Started && container.Ready {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, total)
+}
+
+// restartStatuses returns the containers counted in the RESTARTS column of
+// `kubectl get pods`. While the pod initializes these are the init
+// containers up to the one it waits on; once it is initialized, the sidecars
+// and the regular containers.
+func restartStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	sidecars := restartableInitContainers(pod)
+	var initStatuses, sidecarStatuses []corev1.ContainerStatus
+	initializing := false
+	for _, status := range pod.Status.InitContainerStatuses {
+		initStatuses = append(initStatuses, status)
+		if sidecars[status.Name] {
+			sidecarStatuses = append(sidecarStatuses, status)
+		}
+		if !initContainerDone(status, sidecars) {
+			initializing = true
+			break
+		}
+	}
+	if initializing && !podConditionTrue(pod, corev1.PodInitialized) {
+		return initStatuses
+	}
+	return append(sidecarStatuses, pod.Status.ContainerStatuses...)
+}
+
+// totalRestarts is the RESTARTS column of `kubectl get pods`.
+func totalRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range restartStatuses(pod) {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// lastRestart returns when a container counted by totalRestarts last
+// restarted, i.e. its previous instance finished; nil if none did.
+func lastRestart(pod *corev1.Pod) *time.Time {
+	var last *time.Time
+	for _, status := range restartStatuses(pod) {
+		terminated := status.LastTerminationState.Terminated
+		if status.RestartCount == 0 || terminated == nil || terminated.FinishedAt.IsZero() {
+			continue
+		}
+		if finished := terminated.FinishedAt.Time; last == nil || finished.After(*last) {
+			last = &finished
+		}
+	}
+	return last
+}
+
+// podOwner returns the pod's controller, e.g. its ReplicaSet or Job.
+func podOwner(pod *corev1.Pod) *OwnerReference {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return nil
+	}
+	return &OwnerReference{Kind: ref.Kind, Name: ref.Name}
+}
+
+// matchesPodStatus reports whether the pod matches a comma-separated status
+// filter. Values match the phase or the display status, case-insensitively;
+// "init" and the like match every "Init:..." status.
+func matchesPodStatus(pod *corev1.Pod, display, filter string) bool {
+	phase := strings.ToLower(string(pod.Status.Phase))
+	display = strings.ToLower(display)
+	prefix, _, _ := strings.Cut(display, ":")
+	for _, value := range strings.Split(filter, ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == phase || value == display || value == prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodDisplayStatus(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	waiting := func(reason string) corev1.ContainerState {
+		return corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}
+	}
+	deleted := metav1.NewTime(time.Now())
+
+	tests := []struct {
+		name  string
+		pod   corev1.Pod
+		want  string
+		ready string
+	}{
+		{
+			name: "crash loop",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", State: waiting("CrashLoopBackOff")},
+					{Name: "proxy", Ready: true, State: running},
+				}},
+			},
+			want:  "CrashLoopBackOff",
+			ready: "1/2",
+		},
+		{
+			name: "init progress",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "a"}, {Name: "b"}, {Name: "c"}}, Containers: []corev1.Container{{Name: "app"}}},
+				Status: corev1.PodStatus{Phase: corev1.PodPending, InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "a", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+					{Name: "b", State: running},
+					{Name: "c", State: waiting("PodInitializing")},
+				}},
+			},
+			want:  "Init:1/3",
+			ready: "0/1",
+		},
+		{
+			name: "terminating",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Ready: true, State: running}}},
+			},
+			want:  "Terminating",
+			ready: "1/1",
+		},
+		{
+			name: "evicted",
+			pod: corev1.Pod{
+				Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+			},
+			want:  "Evicted",
+			ready: "0/1",
+		},
+	}
+	for _, tt := range tests {
+		if got := podDisplayStatus(&tt.pod); got != tt.want {
+			t.Errorf("%s: status %q, want %q", tt.name, got, tt.want)
+		}
+		if got := podReadyCount(&tt.pod); got != tt.ready {
+			t.Errorf("%s: ready %q, want %q", tt.name, got, tt.ready)
+		}
+	}
+
+	if !matchesPodStatus(&tests[1].pod, "Init:1/3", "failed,init") || matchesPodStatus(&tests[0].pod, "CrashLoopBackOff", "pending") {
+		t.Fatal("unexpected status filter match")
+	}
+}
+
+func TestPodRestarts(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	started := true
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	later := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	restarted := func(at metav1.Time) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, FinishedAt: at}}
+	}
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "mesh", RestartPolicy: &always}, {Name: "migrate"}, {Name: "seed"}},
+		Containers:     []corev1.Container{{Name: "app"}},
+	}
+
+	tests := []struct {
+		name     string
+		status   corev1.PodStatus
+		restarts int32
+		last     metav1.Time
+	}{
+		{
+			// Init:CrashLoopBackOff: the init containers up to the crashing
+			// one count, the ones after it haven't run yet.
+			name: "initializing",
+			status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "mesh", Started: &started, RestartCount: 2, LastTerminationState: restarted(earlier)},
+				{Name: "migrate", RestartCount: 4, LastTerminationState: restarted(later),
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				{Name: "seed", RestartCount: 7},
+			}},
+			restarts: 6,
+			last:     later,
+		},
+		{
+			name: "initialized",
+			status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodInitialized, Status: corev1.ConditionTrue}},
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "mesh", Started: &started, RestartCount: 2, LastTerminationState: restarted(earlier)},
+					{Name: "migrate", RestartCount: 4, LastTerminationState: restarted(later),
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+					{Name: "seed", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: 3}},
+			},
+			restarts: 5,
+			last:     earlier,
+		},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{Spec: spec, Status: tt.status}
+		if got := totalRestarts(pod); got != tt.restarts {
+			t.Errorf("%s: restarts %d, want %d", tt.name, got, tt.restarts)
+		}
+		if got := lastRestart(pod); got == nil || !got.Equal(tt.last.Time) {
+			t.Errorf("%s: last restart %v, want %v", tt.name, got, tt.last.Time)
+		}
+	}
+}
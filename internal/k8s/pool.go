@@ -0,0 +1,287 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	defaultInformerSyncTimeout = 30 * time.Second
+	minIdleCheck               = 10 * time.Second
+)
+
+// informerSource hands out the informers caching a resource type.
+type informerSource interface {
+	// acquire returns the resource's synced informers, starting them if
+	// needed. It fails with ErrNotPermitted for resources that can't be
+	// cached and ErrCacheWarming if the sync takes too long.
+	acquire(ctx context.Context, resource string) (informerSet, error)
+	// hold is acquire for long-lived use: the informers aren't stopped for
+	// being idle until release is called.
+	hold(ctx context.Context, resource string) (set informerSet, release func(), err error)
+}
+
+// staticInformers serves informers that are managed by the caller, e.g. a
+// factory started by a test.
+type staticInformers map[string]informerSet
+
+func (s staticInformers) acquire(_ context.Context, resource string) (informerSet, error) {
+	set, ok := s[resource]
+	if !ok || len(set) == 0 {
+		return nil, fmt.Errorf("%s: %w", resource, ErrNotPermitted)
+	}
+	return set, nil
+}
+
+func (s staticInformers) hold(ctx context.Context, resource string) (informerSet, func(), error) {
+	set, err := s.acquire(ctx, resource)
+	return set, func() {}, err
+}
+
+// informerPool starts a resource type's informers the first time it is
+// requested and stops them once unused for idleTimeout, so memory is only
+// spent on the kinds people actually look at.
+type informerPool struct {
+	ctx         context.Context // bounds every informer of the pool
+	client      kubernetes.Interface
+	namespaces  []string // namespace-scoped mode, see Cluster.Namespaces
+	syncTimeout time.Duration
+	idleTimeout time.Duration
+	trim        CacheTrim
+	activity    *activityTracker
+	allowed     func(ctx context.Context, resource string) (bool, error)
+
+	mu      sync.Mutex
+	running map[string]*pooledInformer
+	access  map[string]bool // cached access checks for cluster-scoped resources
+}
+
+type pooledInformer struct {
+	set      informerSet
+	cancel   context.CancelFunc
+	synced   chan struct{} // closed once every informer of the set synced
+	lastUsed time.Time
+	holds    int
+}
+
+func (p *informerPool) acquire(ctx context.Context, resource string) (informerSet, error) {
+	entry, err := p.use(ctx, resource, 0)
+	if err != nil {
+		return nil, err
+	}
+	return entry.set, p.waitSynced(ctx, resource, entry)
+}
+
+func (p *informerPool) hold(ctx context.Context, resource string) (informerSet, func(), error) {
+	entry, err := p.use(ctx, resource, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			p.mu.Lock()
+			entry.holds--
+			entry.lastUsed = time.Now()
+			p.mu.Unlock()
+		})
+	}
+	if err := p.waitSynced(ctx, resource, entry); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return entry.set, release, nil
+}
+
+// use returns the running informers of resource, starting them if needed,
+// and marks them as used.
+func (p *informerPool) use(ctx context.Context, resource string, holds int) (*pooledInformer, error) {
+	res, ok := lookupResource(resource)
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q", resource)
+	}
+	if !res.namespaced && len(p.namespaces) > 0 {
+		if err := p.checkAccess(ctx, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.running[resource]
+	if !ok {
+		var err error
+		if entry, err = p.start(res); err != nil {
+			return nil, err
+		}
+		p.running[resource] = entry
+	}
+	entry.lastUsed = time.Now()
+	entry.holds += holds
+	return entry, nil
+}
+
+// checkAccess asks once whether the credentials may list and watch a
+// cluster-scoped resource.
+func (p *informerPool) checkAccess(ctx context.Context, resource string) error {
+	p.mu.Lock()
+	allowed, checked := p.access[resource]
+	p.mu.Unlock()
+	if !checked {
+		var err error
+		if allowed, err = p.allowed(ctx, resource); err != nil {
+			return fmt.Errorf("check access to %s: %w", resource, err)
+		}
+		p.mu.Lock()
+		p.access[resource] = allowed
+		p.mu.Unlock()
+	}
+	if !allowed {
+		return fmt.Errorf("%s: %w", resource, ErrNotPermitted)
+	}
+	return nil
+}
+
+// start runs new informers for res: one per watched namespace in
+// namespace-scoped mode, otherwise one for the whole cluster. Each start
+// gets its own factories so the informers can be stopped independently.
+func (p *informerPool) start(res informerResource) (*pooledInformer, error) {
+	var factories []informers.SharedInformerFactory
+	if res.namespaced && len(p.namespaces) > 0 {
+		for _, namespace := range p.namespaces {
+			factories = append(factories, newInformerFactory(p.client, p.trim, informers.WithNamespace(namespace)))
+		}
+	} else {
+		factories = append(factories, newInformerFactory(p.client, p.trim))
+	}
+	set := make(informerSet, 0, len(factories))
+	for _, factory := range factories {
+		set = append(set, res.informer(factory))
+	}
+	if err := set.addIndexers(res.indexers); err != nil {
+		return nil, fmt.Errorf("index %s informer: %w", res.name, err)
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	activity := p.activity.get(res.name)
+	if err := set.setWatchErrorHandler(func(r *cache.Reflector, err error) {
+		activity.watchError(r, err)
+		cache.DefaultWatchErrorHandler(ctx, r, err)
+	}); err != nil {
+		cancel()
+		return nil, fmt.Errorf("track %s informer: %w", res.name, err)
+	}
+	if _, err := set.addEventHandler(activity.handler()); err != nil {
+		cancel()
+		return nil, fmt.Errorf("track %s informer: %w", res.name, err)
+	}
+	activity.watched() // a fresh start counts as current
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+	}
+	entry := &pooledInformer{set: set, cancel: cancel, synced: make(chan struct{})}
+	go func() {
+		if cache.WaitForCacheSync(ctx.Done(), set.HasSynced) {
+			close(entry.synced)
+		}
+	}()
+	return entry, nil
+}
+
+func (p *informerPool) waitSynced(ctx context.Context, resource string, entry *pooledInformer) error {
+	select {
+	case <-entry.synced:
+		return nil
+	default:
+	}
+	timeout := p.syncTimeout
+	if timeout <= 0 {
+		timeout = defaultInformerSyncTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-entry.synced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		activity := p.activity.get(resource)
+		activity.mu.Lock()
+		lastErr := activity.lastErr
+		activity.mu.Unlock()
+		if lastErr != "" {
+			return fmt.Errorf("%s: %w (last error: %s)", resource, ErrCacheWarming, lastErr)
+		}
+		return fmt.Errorf("%s: %w", resource, ErrCacheWarming)
+	}
+}
+
+// warm starts resource and waits for its sync until stop is closed.
+func (p *informerPool) warm(resource string, stop <-chan struct{}) error {
+	entry, err := p.use(p.ctx, resource, 0)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-entry.synced:
+		return nil
+	case <-stop:
+		return fmt.Errorf("%s: %w", resource, ErrCacheWarming)
+	}
+}
+
+// runJanitor stops idle informers until the pool's context is done.
+func (p *informerPool) runJanitor() {
+	if p.idleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(max(p.idleTimeout/4, minIdleCheck))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.stopIdle(now)
+		}
+	}
+}
+
+func (p *informerPool) stopIdle(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for resource, entry := range p.running {
+		if entry.holds == 0 && now.Sub(entry.lastUsed) > p.idleTimeout {
+			entry.cancel()
+			delete(p.running, resource)
+		}
+	}
+}
+
+// snapshot returns the running informers by resource.
+func (p *informerPool) snapshot() map[string]informerSet {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]informerSet, len(p.running))
+	for resource, entry := range p.running {
+		out[resource] = entry.set
+	}
+	return out
+}
+
+func lookupResource(name string) (informerResource, bool) {
+	for _, res := range informerResources {
+		if res.name == name {
+			return res, true
+		}
+	}
+	return informerResource{}, false
+}
@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInformerPoolStartsAndStopsOnDemand(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}})
+	cluster := &Cluster{Client: client, InformerIdleTimeout: time.Minute}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := cluster.Start(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, running := cluster.pool.snapshot()["deployments"]; running {
+		t.Fatal("deployments started before first use")
+	}
+
+	svc := newService(client, cluster.pool, nil)
+	deployments, err := svc.ListDeployments(ctx, ListOptions{Namespace: "default"})
+	if err != nil || len(deployments) != 1 {
+		t.Fatalf("expected the deployment once started, got %v %v", deployments, err)
+	}
+
+	sub, err := svc.Watch(ctx, WatchOptions{Kinds: []string{"Deployment"}})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	cluster.pool.stopIdle(time.Now().Add(2 * time.Minute))
+	if _, running := cluster.pool.snapshot()["deployments"]; !running {
+		t.Fatal("deployments stopped while watched")
+	}
+
+	sub.Stop()
+	cluster.pool.stopIdle(time.Now().Add(2 * time.Minute))
+	running := cluster.pool.snapshot()
+	if _, ok := running["deployments"]; ok {
+		t.Fatal("idle deployments still running")
+	}
+	if _, ok := running["pods"]; ok {
+		t.Fatal("idle pods still running")
+	}
+}
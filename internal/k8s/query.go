@@ -0,0 +1,369 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// ErrInvalidQuery reports list options that can't be applied, e.g. an
+// unknown sort field.
+var ErrInvalidQuery = errors.New("invalid list query")
+
+const (
+	defaultListLimit = 500
+	maxListLimit     = 5000
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// Page sorts items by opts.Sort and returns the page selected by opts.Limit
+// and opts.Continue (or opts.Offset). Fields are addressed by their JSON
+// names, dotted for nested ones (e.g. "usage.cpuMillicores"); items with the
+// same value are ordered by cluster, namespace and name. Count is the number
+// of items before paging.
+func Page[T any](items []T, opts ListOptions) (ListResponse[T], error) {
+	page, next, err := pageItems(items, opts)
+	if err != nil {
+		return ListResponse[T]{}, err
+	}
+	return ListResponse[T]{Items: page, Count: len(items), Continue: next}, nil
+}
+
+// pageItems implements Page. The continue token holds the position after the
+// page's last item rather than an offset, so objects added or removed since
+// the previous page don't shift the following pages.
+func pageItems[T any](items []T, opts ListOptions) ([]T, string, error) {
+	limit := opts.Limit
+	switch {
+	case limit < 0 || limit > maxListLimit:
+		return nil, "", fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, maxListLimit)
+	case limit == 0:
+		limit = defaultListLimit
+	}
+	desc := false
+	switch strings.ToLower(opts.Order) {
+	case "", OrderAsc:
+	case OrderDesc:
+		desc = true
+	default:
+		return nil, "", fmt.Errorf("%w: order must be %q or %q", ErrInvalidQuery, OrderAsc, OrderDesc)
+	}
+
+	var path []string
+	if opts.Sort != "" {
+		path = strings.Split(opts.Sort, ".")
+		typ, ok := jsonFieldType(reflect.TypeFor[T](), path)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, opts.Sort)
+		}
+		if !sortable(typ) {
+			return nil, "", fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, opts.Sort)
+		}
+	}
+
+	positions := make([]listPosition, len(items))
+	for i := range items {
+		v := reflect.ValueOf(&items[i]).Elem()
+		positions[i] = listPosition{Key: itemKey(v)}
+		if path != nil {
+			positions[i].Value = toSortValue(jsonFieldValue(v, path))
+		}
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	less := func(a, b listPosition) bool {
+		if desc {
+			return b.before(a)
+		}
+		return a.before(b)
+	}
+	sort.Slice(order, func(i, j int) bool { return less(positions[order[i]], positions[order[j]]) })
+
+	start := 0
+	if opts.Continue != "" {
+		token, err := decodeContinue(opts.Continue)
+		if err != nil || token.Sort != opts.Sort || token.Desc != desc {
+			return nil, "", fmt.Errorf("%w: continue token doesn't match the query", ErrInvalidQuery)
+		}
+		start = sort.Search(len(order), func(i int) bool { return less(token.After, positions[order[i]]) })
+	} else if opts.Offset > 0 {
+		start = min(opts.Offset, len(order))
+	}
+	end := min(start+limit, len(order))
+
+	page := make([]T, 0, end-start)
+	for _, i := range order[start:end] {
+		page = append(page, items[i])
+	}
+	next := ""
+	if end < len(order) {
+		next = encodeContinue(continueToken{Sort: opts.Sort, Desc: desc, After: positions[order[end-1]]})
+	}
+	return page, next, nil
+}
+
+// listPosition places an item in a sorted list.
+type listPosition struct {
+	Value sortValue `json:"v"`
+	Key   string    `json:"k"`
+}
+
+func (p listPosition) before(other listPosition) bool {
+	if c := p.Value.compare(other.Value); c != 0 {
+		return c < 0
+	}
+	return p.Key < other.Key
+}
+
+// sortValue holds a field value in comparable form: numbers and booleans in
+// Num, strings and times (fixed-width UTC) in Str.
+type sortValue struct {
+	Num float64 `json:"n,omitempty"`
+	Str string  `json:"s,omitempty"`
+}
+
+func (v sortValue) compare(other sortValue) int {
+	switch {
+	case v.Num < other.Num:
+		return -1
+	case v.Num > other.Num:
+		return 1
+	}
+	return strings.Compare(v.Str, other.Str)
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func sortable(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// toSortValue converts a sortable value; missing ones (nil pointers on the
+// way) sort as zero.
+func toSortValue(v reflect.Value) sortValue {
+	if !v.IsValid() {
+		return sortValue{}
+	}
+	if v.Type() == timeType {
+		return sortValue{Str: v.Interface().(time.Time).UTC().Format("2006-01-02T15:04:05.000000000")}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return sortValue{Str: v.String()}
+	case reflect.Bool:
+		if v.Bool() {
+			return sortValue{Num: 1}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sortValue{Num: float64(v.Int())}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return sortValue{Num: float64(v.Uint())}
+	case reflect.Float32, reflect.Float64:
+		return sortValue{Num: v.Float()}
+	}
+	return sortValue{}
+}
+
+// itemKey identifies an item by its cluster, namespace and name fields, the
+// tie-breaker of every sort.
+func itemKey(v reflect.Value) string {
+	parts := make([]string, 0, 3)
+	for _, field := range []string{"cluster", "namespace", "name"} {
+		if f := jsonFieldValue(v, []string{field}); f.IsValid() && f.Kind() == reflect.String {
+			parts = append(parts, f.String())
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+type continueToken struct {
+	Sort  string       `json:"s,omitempty"`
+	Desc  bool         `json:"d,omitempty"`
+	After listPosition `json:"a"`
+}
+
+func encodeContinue(token continueToken) string {
+	raw, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeContinue(s string) (continueToken, error) {
+	var token continueToken
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return token, err
+	}
+	err = json.Unmarshal(raw, &token)
+	return token, err
+}
+
+// jsonField finds the struct field encoded as name, looking into embedded
+// structs like encoding/json does.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if inner, ok := jsonField(embedded, name); ok {
+					inner.Index = append([]int{i}, inner.Index...)
+					return inner, true
+				}
+			}
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonFieldType resolves a dotted JSON path on t.
+func jsonFieldType(t reflect.Type, path []string) (reflect.Type, bool) {
+	for _, name := range path {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || t == timeType {
+			return nil, false
+		}
+		field, ok := jsonField(t, name)
+		if !ok {
+			return nil, false
+		}
+		t = field.Type
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+// jsonFieldValue resolves a dotted JSON path on v; the result is invalid if
+// the path is unknown or crosses a nil pointer.
+func jsonFieldValue(v reflect.Value, path []string) reflect.Value {
+	for _, name := range path {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		field, ok := jsonField(v.Type(), name)
+		if !ok {
+			return reflect.Value{}
+		}
+		var err error
+		if v, err = v.FieldByIndexErr(field.Index); err != nil {
+			return reflect.Value{}
+		}
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// Project reduces items to the given dotted JSON fields, keeping their
+// nesting, for ?fields= selections.
+func Project[T any](items []T, paths []string) ([]map[string]any, error) {
+	for _, path := range paths {
+		if _, ok := jsonFieldType(reflect.TypeFor[T](), strings.Split(path, ".")); !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, path)
+		}
+	}
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]any
+		if err := json.Unmarshal(raw, &full); err != nil {
+			return nil, err
+		}
+		projected := make(map[string]any, len(paths))
+		for _, path := range paths {
+			copyPath(projected, full, strings.Split(path, "."))
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
+
+func copyPath(dst, src map[string]any, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	child, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		dst[path[0]] = child
+	}
+	copyPath(child, nested, path[1:])
+}
+
+// parseFieldSelector parses a field selector like the API server's, limited
+// to the fields in supported.
+func parseFieldSelector(raw string, supported fields.Set) (fields.Selector, error) {
+	if raw == "" {
+		return fields.Everything(), nil
+	}
+	selector, err := fields.ParseSelector(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	for _, req := range selector.Requirements() {
+		if _, ok := supported[req.Field]; !ok {
+			return nil, fmt.Errorf("%w: unsupported field selector %q", ErrInvalidQuery, req.Field)
+		}
+	}
+	return selector, nil
+}
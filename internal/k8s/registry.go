@@ -0,0 +1,498 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kubezen/internal/config"
+)
+
+type ClusterSource string
+
+const (
+	ClusterSourceKubeconfig ClusterSource = "kubeconfig"
+	ClusterSourceAPI        ClusterSource = "api"
+	ClusterSourceInCluster  ClusterSource = "in-cluster"
+
+	inClusterName = "in-cluster"
+
+	warmBackoff    = time.Second
+	maxWarmBackoff = time.Minute
+)
+
+var (
+	ErrClusterNotFound = errors.New("cluster not found")
+	ErrClusterExists   = errors.New("cluster already registered")
+	// ErrCacheWarming means the cluster's informers haven't synced yet.
+	ErrCacheWarming = errors.New("cache warming up, try again shortly")
+)
+
+// ClusterInfo describes a registered cluster for listing.
+type ClusterInfo struct {
+	Name        string        `json:"name"`
+	Source      ClusterSource `json:"source"`
+	Context     string        `json:"context,omitempty"`
+	KubeCluster string        `json:"kubeCluster,omitempty"` // cluster entry in the kubeconfig
+	User        string        `json:"user,omitempty"`
+	Namespace   string        `json:"namespace,omitempty"`
+	Server      string        `json:"server,omitempty"`
+	Default     bool          `json:"default"`
+	Started     bool          `json:"started"`
+	Synced      bool          `json:"synced"`
+	Restarts    int           `json:"restarts,omitempty"` // informer restarts by the watchdog
+	Error       string        `json:"error,omitempty"`
+}
+
+// Registry holds one Cluster and Service per registered cluster. Informers are
+// started the first time a cluster is used.
+type Registry struct {
+	ctx         context.Context
+	cfg         config.KubeConfig
+	defaultName string
+
+	mu       sync.RWMutex
+	clusters map[string]*registryEntry
+}
+
+type registryEntry struct {
+	name        string
+	source      ClusterSource
+	context     string
+	kubeCluster string
+	user        string
+	namespace   string
+	server      string
+	build       func() (*Cluster, error)
+
+	mu       sync.Mutex
+	cluster  *Cluster
+	service  *Service
+	ready    chan struct{} // closed once the current start attempt finished
+	synced   bool
+	err      error
+	cancel   context.CancelFunc
+	restarts int
+	removed  bool
+	lastErr  error // error of the last failed attempt, kept across retries
+}
+
+// NewRegistry registers every context of the configured kubeconfig, or the
+// in-cluster config when there is no kubeconfig. ctx bounds the lifetime of
+// all informers started by the registry.
+func NewRegistry(ctx context.Context, cfg config.KubeConfig) (*Registry, error) {
+	r := &Registry{
+		ctx:      ctx,
+		cfg:      cfg,
+		clusters: make(map[string]*registryEntry),
+	}
+
+	path := KubeconfigPath(cfg)
+	if path == "" {
+		r.defaultName = inClusterName
+		r.clusters[inClusterName] = &registryEntry{
+			name:   inClusterName,
+			source: ClusterSourceInCluster,
+			build:  func() (*Cluster, error) { return NewCluster(cfg) },
+		}
+		return r, nil
+	}
+
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig %s: %w", path, err)
+	}
+	r.defaultName = cfg.Context
+	if r.defaultName == "" {
+		r.defaultName = kubeconfig.CurrentContext
+	}
+	if _, ok := kubeconfig.Contexts[r.defaultName]; !ok {
+		return nil, fmt.Errorf("context %q not found in %s", r.defaultName, path)
+	}
+
+	for name, kubeContext := range kubeconfig.Contexts {
+		contextCfg := cfg
+		contextCfg.KubeconfigPath = path
+		contextCfg.Context = name
+		entry := &registryEntry{
+			name:        name,
+			source:      ClusterSourceKubeconfig,
+			context:     name,
+			kubeCluster: kubeContext.Cluster,
+			user:        kubeContext.AuthInfo,
+			namespace:   kubeContext.Namespace,
+			build:       func() (*Cluster, error) { return NewCluster(contextCfg) },
+		}
+		if cluster, ok := kubeconfig.Clusters[kubeContext.Cluster]; ok {
+			entry.server = cluster.Server
+		}
+		r.clusters[name] = entry
+	}
+	return r, nil
+}
+
+// DefaultName is the cluster served when a request doesn't pick one.
+func (r *Registry) DefaultName() string {
+	return r.defaultName
+}
+
+// Add registers a cluster from raw kubeconfig content. The kubeconfig is
+// sanitized and validated up front, see SanitizeKubeconfig, but informers
+// only start on first use.
+func (r *Registry) Add(name string, rawKubeconfig []byte, contextName string) error {
+	sanitized, err := SanitizeKubeconfig(rawKubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	restConfig, err := restConfigFromBytes(sanitized, contextName, r.cfg)
+	if err != nil {
+		return err
+	}
+	kubeconfig, err := clientcmd.Load(sanitized)
+	if err != nil {
+		return err
+	}
+	kubeContext := kubeconfig.Contexts[contextName]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clusters[name]; ok {
+		return ErrClusterExists
+	}
+	r.clusters[name] = &registryEntry{
+		name:        name,
+		source:      ClusterSourceAPI,
+		context:     contextName,
+		kubeCluster: kubeContext.Cluster,
+		user:        kubeContext.AuthInfo,
+		namespace:   kubeContext.Namespace,
+		server:      restConfig.Host,
+		build:       func() (*Cluster, error) { return newClusterForConfig(rest.CopyConfig(restConfig), r.cfg) },
+	}
+	return nil
+}
+
+// AddUnavailable registers an API cluster that can't be loaded, e.g. because
+// its stored kubeconfig no longer decrypts, so it is listed with cause as its
+// error and can still be removed.
+func (r *Registry) AddUnavailable(name string, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clusters[name]; ok {
+		return ErrClusterExists
+	}
+	r.clusters[name] = &registryEntry{
+		name:    name,
+		source:  ClusterSourceAPI,
+		build:   func() (*Cluster, error) { return nil, cause },
+		err:     cause,
+		lastErr: cause,
+	}
+	return nil
+}
+
+// Remove stops a cluster's informers and unregisters it. Only clusters added
+// through the API can be removed.
+func (r *Registry) Remove(name string) error {
+	r.mu.Lock()
+	entry, ok := r.clusters[name]
+	if !ok {
+		r.mu.Unlock()
+		return ErrClusterNotFound
+	}
+	if entry.source != ClusterSourceAPI {
+		r.mu.Unlock()
+		return fmt.Errorf("cluster %q comes from %s and cannot be removed", name, entry.source)
+	}
+	delete(r.clusters, name)
+	r.mu.Unlock()
+
+	entry.stop()
+	return nil
+}
+
+// Has reports whether a cluster is registered.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.entry(name)
+	return ok
+}
+
+// Service returns the Service for a cluster, starting its informers and
+// waiting for the cache to sync if needed. ctx only bounds the wait; the
+// informers keep running for the registry's lifetime.
+func (r *Registry) Service(ctx context.Context, name string) (*Service, error) {
+	entry, ok := r.entry(name)
+	if !ok {
+		return nil, ErrClusterNotFound
+	}
+
+	for {
+		ready := entry.start(r.ctx, r.cfg.SyncTimeout)
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		entry.mu.Lock()
+		// Another caller may already be retrying a failed attempt; wait for
+		// that one instead of handing out an unsynced service.
+		if entry.ready == ready {
+			service, err := entry.service, entry.err
+			entry.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			return service, nil
+		}
+		entry.mu.Unlock()
+	}
+}
+
+// ReadyService returns the Service for a cluster without waiting. Until the
+// cache synced it starts the informers if needed and returns ErrCacheWarming,
+// wrapped with the last failure if a previous attempt failed.
+func (r *Registry) ReadyService(name string) (*Service, error) {
+	entry, ok := r.entry(name)
+	if !ok {
+		return nil, ErrClusterNotFound
+	}
+	ready := entry.start(r.ctx, r.cfg.SyncTimeout)
+	select {
+	case <-ready:
+	default:
+		entry.mu.Lock()
+		lastErr := entry.lastErr
+		entry.mu.Unlock()
+		if lastErr != nil {
+			return nil, fmt.Errorf("%w (last attempt: %v)", ErrCacheWarming, lastErr)
+		}
+		return nil, ErrCacheWarming
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.ready != ready {
+		// A failed attempt was just retried by someone else.
+		return nil, ErrCacheWarming
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry.service, nil
+}
+
+// Warm starts a cluster's informers in the background, retrying failed syncs
+// with exponential backoff until they succeed or ctx is done.
+func (r *Registry) Warm(ctx context.Context, name string, logger *slog.Logger) {
+	backoff := warmBackoff
+	for {
+		logger.Info("starting informer cache...", slog.String("cluster", name))
+		_, err := r.Service(ctx, name)
+		if err == nil {
+			logger.Info("informer cache synced", slog.String("cluster", name))
+			return
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrClusterNotFound) {
+			return
+		}
+		logger.Warn("informer sync failed, retrying",
+			slog.String("cluster", name),
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWarmBackoff)
+	}
+}
+
+// Cluster returns a started cluster, see Service.
+func (r *Registry) Cluster(ctx context.Context, name string) (*Cluster, error) {
+	if _, err := r.Service(ctx, name); err != nil {
+		return nil, err
+	}
+	entry, ok := r.entry(name)
+	if !ok {
+		// Removed since the service was returned.
+		return nil, ErrClusterNotFound
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return entry.cluster, nil
+}
+
+// List describes all registered clusters, sorted by name.
+func (r *Registry) List() []ClusterInfo {
+	r.mu.RLock()
+	entries := make([]*registryEntry, 0, len(r.clusters))
+	for _, entry := range r.clusters {
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	out := make([]ClusterInfo, 0, len(entries))
+	for _, entry := range entries {
+		entry.mu.Lock()
+		info := ClusterInfo{
+			Name:        entry.name,
+			Source:      entry.source,
+			Context:     entry.context,
+			KubeCluster: entry.kubeCluster,
+			User:        entry.user,
+			Namespace:   entry.namespace,
+			Server:      entry.server,
+			Default:     entry.name == r.defaultName,
+			Started:     entry.ready != nil,
+			Synced:      entry.synced,
+			Restarts:    entry.restarts,
+		}
+		if entry.err != nil {
+			info.Error = entry.err.Error()
+		}
+		entry.mu.Unlock()
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (r *Registry) entry(name string) (*registryEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.clusters[name]
+	return entry, ok
+}
+
+// start kicks off informers unless a start is running or has succeeded. A
+// failed attempt is retried on the next call with a fresh informer factory.
+func (e *registryEntry) start(parent context.Context, timeout time.Duration) <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ready != nil {
+		select {
+		case <-e.ready:
+			if e.err == nil {
+				return e.ready
+			}
+		default:
+			return e.ready
+		}
+	}
+
+	ready := make(chan struct{})
+	e.ready = ready
+	e.err = nil
+	ctx, cancel := context.WithCancel(parent)
+	e.cancel = cancel
+
+	go func() {
+		err := e.run(ctx, timeout)
+		if err != nil {
+			cancel()
+		}
+		e.mu.Lock()
+		e.err = err
+		e.synced = err == nil
+		if err != nil {
+			e.lastErr = err
+		}
+		e.mu.Unlock()
+		close(ready)
+	}()
+	return ready
+}
+
+func (e *registryEntry) run(ctx context.Context, timeout time.Duration) error {
+	cluster, err := e.build()
+	if err != nil {
+		return fmt.Errorf("cluster %s: %w", e.name, err)
+	}
+	e.mu.Lock()
+	e.cluster = cluster
+	e.mu.Unlock()
+
+	if err := startCluster(ctx, cluster, timeout, e.name); err != nil {
+		return err
+	}
+	// The service draws its informers from the pool start created.
+	service := newClusterService(cluster)
+	e.mu.Lock()
+	e.service = service
+	e.mu.Unlock()
+	return nil
+}
+
+// restart replaces the cluster's informers with fresh ones. The old cache
+// keeps serving until the new one synced; on failure it stays in place.
+func (e *registryEntry) restart(parent context.Context, timeout time.Duration) error {
+	cluster, err := e.build()
+	if err != nil {
+		return fmt.Errorf("cluster %s: %w", e.name, err)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	if err := startCluster(ctx, cluster, timeout, e.name); err != nil {
+		cancel()
+		return err
+	}
+
+	e.mu.Lock()
+	if e.removed {
+		e.mu.Unlock()
+		cancel()
+		return ErrClusterNotFound
+	}
+	oldCancel, oldService := e.cancel, e.service
+	e.cluster, e.service, e.cancel = cluster, newClusterService(cluster), cancel
+	e.restarts++
+	e.mu.Unlock()
+
+	if oldCancel != nil {
+		oldCancel()
+	}
+	if oldService != nil {
+		oldService.stop()
+	}
+	return nil
+}
+
+func newClusterService(cluster *Cluster) *Service {
+	service := newService(cluster.Client, cluster.pool, cluster.Namespaces)
+	service.metrics = newMetricsCache(cluster.Metrics, cluster.Namespaces)
+	service.cluster = cluster
+	return service
+}
+
+// startCluster runs the informers on ctx; only the wait for the initial sync
+// is bounded by timeout.
+func startCluster(ctx context.Context, cluster *Cluster, timeout time.Duration, name string) error {
+	syncCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		syncCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := cluster.start(ctx, syncCtx.Done()); err != nil {
+		return fmt.Errorf("cluster %s: %w", name, err)
+	}
+	return nil
+}
+
+func (e *registryEntry) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removed = true
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubezen/internal/config"
+)
+
+func newTestRegistry(t *testing.T, clusters map[string]*fake.Clientset) *Registry {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	r := &Registry{
+		ctx:      ctx,
+		cfg:      config.KubeConfig{SyncTimeout: 5 * time.Second},
+		clusters: make(map[string]*registryEntry),
+	}
+	for name, client := range clusters {
+		if r.defaultName == "" || name < r.defaultName {
+			r.defaultName = name
+		}
+		r.clusters[name] = &registryEntry{
+			name:   name,
+			source: ClusterSourceKubeconfig,
+			build: func() (*Cluster, error) {
+				return &Cluster{Client: client}, nil
+			},
+		}
+	}
+	return r
+}
+
+func TestRegistryStartsClustersLazily(t *testing.T) {
+	dev := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "dev-pod", Namespace: "default"}})
+	prod := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "prod-pod", Namespace: "default"}})
+	registry := newTestRegistry(t, map[string]*fake.Clientset{"dev": dev, "prod": prod})
+
+	for _, info := range registry.List() {
+		if info.Started {
+			t.Fatalf("cluster %s started before first use", info.Name)
+		}
+	}
+
+	svc, err := registry.Service(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	pods, err := svc.ListPods(context.Background(), ListOptions{})
+	if err != nil || len(pods) != 1 || pods[0].Name != "prod-pod" {
+		t.Fatalf("expected prod pods, got %v %v", pods, err)
+	}
+
+	for _, info := range registry.List() {
+		if want := info.Name == "prod"; info.Started != want || info.Synced != want {
+			t.Fatalf("unexpected state for %s: %+v", info.Name, info)
+		}
+	}
+
+	if _, err := registry.Service(context.Background(), "missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRegistryRemoveOnlyAPIClusters(t *testing.T) {
+	registry := newTestRegistry(t, map[string]*fake.Clientset{"dev": fake.NewSimpleClientset()})
+	if err := registry.Remove("dev"); err == nil {
+		t.Fatalf("expected kubeconfig cluster removal to fail")
+	}
+	if err := registry.Remove("missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRegistryListsUnavailableClusters(t *testing.T) {
+	registry := newTestRegistry(t, map[string]*fake.Clientset{"dev": fake.NewSimpleClientset()})
+	cause := errors.New("decrypt kubeconfig: cipher: message authentication failed")
+	if err := registry.AddUnavailable("prod", cause); err != nil {
+		t.Fatalf("add unavailable: %v", err)
+	}
+	if err := registry.AddUnavailable("dev", cause); !errors.Is(err, ErrClusterExists) {
+		t.Fatalf("expected exists, got %v", err)
+	}
+
+	var info *ClusterInfo
+	for _, cluster := range registry.List() {
+		if cluster.Name == "prod" {
+			info = &cluster
+		}
+	}
+	if info == nil || info.Source != ClusterSourceAPI || info.Synced || info.Error != cause.Error() {
+		t.Fatalf("unexpected cluster info %+v", info)
+	}
+	if _, err := registry.Service(context.Background(), "prod"); !errors.Is(err, cause) {
+		t.Fatalf("expected the cause, got %v", err)
+	}
+	if err := registry.Remove("prod"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+}
+
+func TestReadyServiceDoesNotWaitForSync(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	registry := newTestRegistry(t, map[string]*fake.Clientset{"dev": client})
+	gate := make(chan struct{})
+	build := registry.clusters["dev"].build
+	registry.clusters["dev"].build = func() (*Cluster, error) {
+		<-gate
+		return build()
+	}
+
+	if _, err := registry.ReadyService("dev"); !errors.Is(err, ErrCacheWarming) {
+		t.Fatalf("expected ErrCacheWarming, got %v", err)
+	}
+	close(gate)
+
+	if _, err := registry.Service(context.Background(), "dev"); err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	if svc, err := registry.ReadyService("dev"); err != nil || svc == nil {
+		t.Fatalf("expected synced service, got %v", err)
+	}
+}
+
+func TestRegistryAddRejectsUnsafeKubeconfig(t *testing.T) {
+	const kubeconfig = `apiVersion: v1
+kind: Config
+current-context: prod
+contexts:
+- name: prod
+  context: {cluster: prod, user: %s}
+- name: other
+  context: {cluster: prod, user: exec}
+clusters:
+- name: prod
+  cluster: {server: "https://prod.example.com"}
+users:
+- name: inline
+  user: {token: secret}
+- name: exec
+  user:
+    exec: {apiVersion: client.authentication.k8s.io/v1, command: /bin/sh}
+- name: files
+  user: {client-certificate: /etc/passwd, client-key: /etc/shadow}
+`
+	registry := newTestRegistry(t, nil)
+	for _, user := range []string{"exec", "files"} {
+		err := registry.Add("prod-"+user, []byte(fmt.Sprintf(kubeconfig, user)), "prod")
+		if !errors.Is(err, ErrUnsafeKubeconfig) {
+			t.Fatalf("expected %s credentials to be rejected, got %v", user, err)
+		}
+	}
+
+	// Unsafe users of other contexts are dropped rather than rejected.
+	sanitized, err := SanitizeKubeconfig([]byte(fmt.Sprintf(kubeconfig, "inline")), "prod")
+	if err != nil {
+		t.Fatalf("sanitize: %v", err)
+	}
+	if strings.Contains(string(sanitized), "/bin/sh") {
+		t.Fatalf("expected other contexts' users to be dropped:\n%s", sanitized)
+	}
+	if err := registry.Add("prod", sanitized, "prod"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	RolloutProgressing = "progressing"
+	RolloutComplete    = "complete"
+	RolloutFailed      = "failed"
+
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// RolloutStatus is a point-in-time view of a Deployment rollout, mirroring
+// `kubectl rollout status`.
+type RolloutStatus struct {
+	Namespace          string                `json:"namespace"`
+	Name               string                `json:"name"`
+	Revision           string                `json:"revision,omitempty"`
+	Generation         int64                 `json:"generation"`
+	ObservedGeneration int64                 `json:"observedGeneration"`
+	DesiredReplicas    int32                 `json:"desiredReplicas"`
+	Replicas           int32                 `json:"replicas"`
+	UpdatedReplicas    int32                 `json:"updatedReplicas"`
+	ReadyReplicas      int32                 `json:"readyReplicas"`
+	AvailableReplicas  int32                 `json:"availableReplicas"`
+	Conditions         []DeploymentCondition `json:"conditions"`
+	NewReplicaSet      string                `json:"newReplicaSet,omitempty"`
+	Pods               []PodSummary          `json:"pods"`
+	Result             string                `json:"result"`
+	Message            string                `json:"message"`
+}
+
+// Done reports whether the rollout reached a final result.
+func (r RolloutStatus) Done() bool {
+	return r.Result != RolloutProgressing
+}
+
+// RolloutStatus evaluates a Deployment's rollout from the cache.
+func (s *Service) RolloutStatus(ctx context.Context, namespace, name string) (RolloutStatus, error) {
+	lister, err := s.deploymentLister(ctx)
+	if err != nil {
+		return RolloutStatus{}, fmt.Errorf("get deployment: %w", err)
+	}
+	deploy, err := lister.Deployments(namespace).Get(name)
+	if err != nil {
+		return RolloutStatus{}, fmt.Errorf("get deployment: %w", err)
+	}
+
+	status := RolloutStatus{
+		Namespace:          deploy.Namespace,
+		Name:               deploy.Name,
+		Revision:           deploy.Annotations[revisionAnnotation],
+		Generation:         deploy.Generation,
+		ObservedGeneration: deploy.Status.ObservedGeneration,
+		DesiredReplicas:    1,
+		Replicas:           deploy.Status.Replicas,
+		UpdatedReplicas:    deploy.Status.UpdatedReplicas,
+		ReadyReplicas:      deploy.Status.ReadyReplicas,
+		AvailableReplicas:  deploy.Status.AvailableReplicas,
+		Conditions:         toDeploymentConditions(deploy),
+		Pods:               []PodSummary{},
+	}
+	if deploy.Spec.Replicas != nil {
+		status.DesiredReplicas = *deploy.Spec.Replicas
+	}
+	status.Result, status.Message = rolloutResult(deploy)
+
+	if rs := s.newReplicaSet(ctx, deploy); rs != nil {
+		status.NewReplicaSet = rs.Name
+		status.Pods = s.podsOwnedBy(ctx, rs.UID)
+	}
+	return status, nil
+}
+
+// rolloutResult follows kubectl's DeploymentStatusViewer.
+func rolloutResult(deploy *appsv1.Deployment) (string, string) {
+	if deploy.Generation > deploy.Status.ObservedGeneration {
+		return RolloutProgressing, "Waiting for deployment spec update to be observed..."
+	}
+	for _, cond := range deploy.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return RolloutFailed, fmt.Sprintf("deployment %q exceeded its progress deadline", deploy.Name)
+		}
+	}
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+	st := deploy.Status
+	switch {
+	case st.UpdatedReplicas < desired:
+		return RolloutProgressing, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", deploy.Name, st.UpdatedReplicas, desired)
+	case st.Replicas > st.UpdatedReplicas:
+		return RolloutProgressing, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", deploy.Name, st.Replicas-st.UpdatedReplicas)
+	case st.AvailableReplicas < st.UpdatedReplicas:
+		return RolloutProgressing, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", deploy.Name, st.AvailableReplicas, st.UpdatedReplicas)
+	}
+	return RolloutComplete, fmt.Sprintf("deployment %q successfully rolled out", deploy.Name)
+}
+
+// newReplicaSet returns the ReplicaSet of the Deployment's current revision.
+func (s *Service) newReplicaSet(ctx context.Context, deploy *appsv1.Deployment) *appsv1.ReplicaSet {
+	revision := deploy.Annotations[revisionAnnotation]
+	if revision == "" {
+		return nil
+	}
+	replicaSets, err := s.byIndex(ctx, "replicasets", indexByOwner, string(deploy.UID))
+	if err != nil {
+		return nil
+	}
+	for _, obj := range replicaSets {
+		rs, ok := obj.(*appsv1.ReplicaSet)
+		if !ok {
+			continue
+		}
+		if owner := metav1.GetControllerOf(rs); owner == nil || owner.UID != deploy.UID {
+			continue
+		}
+		if rs.Annotations[revisionAnnotation] == revision {
+			return rs
+		}
+	}
+	return nil
+}
+
+func (s *Service) podsOwnedBy(ctx context.Context, uid types.UID) []PodSummary {
+	pods, err := s.byIndex(ctx, "pods", indexByOwner, string(uid))
+	if err != nil {
+		return []PodSummary{}
+	}
+	out := make([]PodSummary, 0, len(pods))
+	for _, obj := range pods {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.UID == uid {
+			out = append(out, toPodSummary(pod))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
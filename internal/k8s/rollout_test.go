@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", UID: "deploy-uid", Generation: 2,
+			Annotations: map[string]string{revisionAnnotation: "2"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           4,
+			UpdatedReplicas:    3,
+			AvailableReplicas:  3,
+		},
+	}
+	controller := true
+	ownedBy := func(kind, name, uid string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(uid), Controller: &controller}}
+	}
+	oldRS := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-1", Namespace: "default", UID: "rs-1",
+		Annotations: map[string]string{revisionAnnotation: "1"}, OwnerReferences: ownedBy("Deployment", "web", "deploy-uid"),
+	}}
+	newRS := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-2", Namespace: "default", UID: "rs-2",
+		Annotations: map[string]string{revisionAnnotation: "2"}, OwnerReferences: ownedBy("Deployment", "web", "deploy-uid"),
+	}}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1-a", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "web-1", "rs-1")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2-a", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "web-2", "rs-2")}},
+	}
+
+	svc := newTestService(t, pods, nil, []*appsv1.Deployment{deploy}, nil)
+	rsIndexer := svc.informers.(staticInformers)["replicasets"][0].GetIndexer()
+	_ = rsIndexer.Add(oldRS)
+	_ = rsIndexer.Add(newRS)
+
+	status, err := svc.RolloutStatus(context.Background(), "default", "web")
+	if err != nil {
+		t.Fatalf("rollout status: %v", err)
+	}
+	if status.Result != RolloutProgressing || status.Message != `Waiting for deployment "web" rollout to finish: 1 old replicas are pending termination...` {
+		t.Fatalf("unexpected progress: %s %q", status.Result, status.Message)
+	}
+	if status.NewReplicaSet != "web-2" || len(status.Pods) != 1 || status.Pods[0].Name != "web-2-a" {
+		t.Fatalf("unexpected new replica set view: %s %+v", status.NewReplicaSet, status.Pods)
+	}
+
+	deploy.Status.Replicas = 3
+	if result, _ := rolloutResult(deploy); result != RolloutComplete {
+		t.Fatalf("expected complete, got %s", result)
+	}
+	deploy.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}}
+	if result, _ := rolloutResult(deploy); result != RolloutFailed {
+		t.Fatalf("expected failed, got %s", result)
+	}
+}
@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// The *Fields functions expose the object fields list queries can select on,
+// mostly the ones the API server supports for the resource.
+
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
+
+func nodeFields(node *corev1.Node) fields.Set {
+	return fields.Set{
+		"metadata.name":      node.Name,
+		"spec.unschedulable": strconv.FormatBool(node.Spec.Unschedulable),
+	}
+}
+
+func deploymentFields(deploy *appsv1.Deployment) fields.Set {
+	return fields.Set{
+		"metadata.name":      deploy.Name,
+		"metadata.namespace": deploy.Namespace,
+	}
+}
+
+func namespaceFields(ns *corev1.Namespace) fields.Set {
+	return fields.Set{
+		"metadata.name": ns.Name,
+		"status.phase":  string(ns.Status.Phase),
+	}
+}
+
+func eventFields(ev *corev1.Event) fields.Set {
+	return fields.Set{
+		"metadata.name":             ev.Name,
+		"metadata.namespace":        ev.Namespace,
+		"involvedObject.kind":       ev.InvolvedObject.Kind,
+		"involvedObject.namespace":  ev.InvolvedObject.Namespace,
+		"involvedObject.name":       ev.InvolvedObject.Name,
+		"involvedObject.uid":        string(ev.InvolvedObject.UID),
+		"involvedObject.apiVersion": ev.InvolvedObject.APIVersion,
+		"involvedObject.fieldPath":  ev.InvolvedObject.FieldPath,
+		"reason":                    ev.Reason,
+		"reportingComponent":        ev.ReportingController,
+		"source":                    ev.Source.Component,
+		"type":                      ev.Type,
+	}
+}
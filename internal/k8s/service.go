@@ -2,13 +2,18 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	appsinformers "k8s.io/client-go/informers/apps"
 	coreinformers "k8s.io/client-go/informers/core"
@@ -20,25 +25,30 @@ import (
 // Service exposes read-only operations backed by informer caches.
 type Service struct {
 	client       kubernetes.Interface
-	pods         corelisters.PodLister
-	nodes        corelisters.NodeLister
-	deployments  appslisters.DeploymentLister
-	namespaces   corelisters.NamespaceLister
-	events       corelisters.EventLister
+	informers    informerSource
+	scope        []string // watched namespaces, empty for all
+	metrics      *metricsCache
+	cluster      *Cluster // set by the registry; reports cache freshness
 	defaultSince func(time.Time) string
+
+	stopped  chan struct{} // closed when the informers were replaced
+	stopOnce sync.Once
 }
 
+// NewService serves from the informers of factory, which the caller starts.
 func NewService(client kubernetes.Interface, factory informerFactory) *Service {
+	return newService(client, staticInformers(factoryInformers(factory)), nil)
+}
+
+func newService(client kubernetes.Interface, source informerSource, scope []string) *Service {
 	return &Service{
-		client:      client,
-		pods:        factory.Core().V1().Pods().Lister(),
-		nodes:       factory.Core().V1().Nodes().Lister(),
-		deployments: factory.Apps().V1().Deployments().Lister(),
-		namespaces:  factory.Core().V1().Namespaces().Lister(),
-		events:      factory.Core().V1().Events().Lister(),
+		client:    client,
+		informers: source,
+		scope:     scope,
 		defaultSince: func(t time.Time) string {
 			return humanizeDuration(time.Since(t))
 		},
+		stopped: make(chan struct{}),
 	}
 }
 
@@ -48,53 +58,154 @@ type informerFactory interface {
 	Apps() appsinformers.Interface
 }
 
-func (s *Service) ListPods(ctx context.Context, opts ListOptions) ([]PodSummary, int, error) {
-	selector := labels.Everything()
-	if opts.LabelSelector != "" {
-		parsed, err := labels.Parse(opts.LabelSelector)
-		if err != nil {
-			return nil, 0, fmt.Errorf("invalid label selector: %w", err)
-		}
-		selector = parsed
+// The lister accessors start the resource's informers on first use; see
+// informerSource.acquire for the errors.
+
+func (s *Service) podLister(ctx context.Context) (corelisters.PodLister, error) {
+	set, err := s.informers.acquire(ctx, "pods")
+	if err != nil {
+		return nil, err
+	}
+	return corelisters.NewPodLister(set.indexer()), nil
+}
+
+func (s *Service) nodeLister(ctx context.Context) (corelisters.NodeLister, error) {
+	set, err := s.informers.acquire(ctx, "nodes")
+	if err != nil {
+		return nil, err
+	}
+	return corelisters.NewNodeLister(set.indexer()), nil
+}
+
+func (s *Service) deploymentLister(ctx context.Context) (appslisters.DeploymentLister, error) {
+	set, err := s.informers.acquire(ctx, "deployments")
+	if err != nil {
+		return nil, err
+	}
+	return appslisters.NewDeploymentLister(set.indexer()), nil
+}
+
+func (s *Service) namespaceLister(ctx context.Context) (corelisters.NamespaceLister, error) {
+	set, err := s.informers.acquire(ctx, "namespaces")
+	if err != nil {
+		return nil, err
+	}
+	return corelisters.NewNamespaceLister(set.indexer()), nil
+}
+
+// byIndex returns the cached objects of resource matching value in index,
+// see indexes.go.
+func (s *Service) byIndex(ctx context.Context, resource, index, value string) ([]any, error) {
+	set, err := s.informers.acquire(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	return set.indexer().ByIndex(index, value)
+}
+
+func (s *Service) eventLister(ctx context.Context) (corelisters.EventLister, error) {
+	set, err := s.informers.acquire(ctx, "events")
+	if err != nil {
+		return nil, err
 	}
+	return corelisters.NewEventLister(set.indexer()), nil
+}
 
+// stop marks the service as replaced, ending its watch subscriptions.
+func (s *Service) stop() {
+	s.stopOnce.Do(func() { close(s.stopped) })
+}
+
+// CacheAge reports how stale the cached resource type (e.g. "pods") may be,
+// in whole seconds, or nil when unknown.
+func (s *Service) CacheAge(resource string) *int64 {
+	if s.cluster == nil {
+		return nil
+	}
+	age, ok := s.cluster.CacheAge(resource)
+	if !ok {
+		return nil
+	}
+	seconds := int64(age / time.Second)
+	return &seconds
+}
+
+// ListPods returns every pod matching opts' filters, unsorted; see Page for
+// sorting and paging.
+func (s *Service) ListPods(ctx context.Context, opts ListOptions) ([]PodSummary, error) {
+	labelSelector, fieldSelector, err := listSelectors(opts, podFields(&corev1.Pod{}))
+	if err != nil {
+		return nil, err
+	}
+
+	lister, err := s.podLister(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	namespace := opts.Namespace
+	if namespace == "all" {
+		namespace = ""
+	}
 	var pods []*corev1.Pod
-	var err error
-	if opts.Namespace != "" && opts.Namespace != "all" {
-		pods, err = s.pods.Pods(opts.Namespace).List(selector)
+	if node, ok := fieldSelector.RequiresExactMatch("spec.nodeName"); ok && node != "" {
+		// The node index only visits the node's pods.
+		objs, err := s.byIndex(ctx, "pods", indexByNode, node)
+		if err != nil {
+			return nil, fmt.Errorf("list pods: %w", err)
+		}
+		for _, obj := range objs {
+			if pod, ok := obj.(*corev1.Pod); ok && (namespace == "" || pod.Namespace == namespace) && labelSelector.Matches(labels.Set(pod.Labels)) {
+				pods = append(pods, pod)
+			}
+		}
+	} else if namespace != "" {
+		pods, err = lister.Pods(namespace).List(labelSelector)
 	} else {
-		pods, err = s.pods.List(selector)
+		pods, err = lister.List(labelSelector)
 	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("list pods: %w", err)
+		return nil, fmt.Errorf("list pods: %w", err)
 	}
 
 	if err := ctx.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	query := strings.TrimSpace(strings.ToLower(opts.Query))
 	statusFilter := strings.TrimSpace(strings.ToLower(opts.Status))
 
+	usage := s.podUsageLookup(ctx)
 	filtered := make([]PodSummary, 0, len(pods))
 	for _, pod := range pods {
-		if statusFilter != "" && statusFilter != "all" && strings.ToLower(string(pod.Status.Phase)) != statusFilter {
+		if query != "" && !matchesPodQuery(pod, query) {
 			continue
 		}
 
-		if query != "" && !matchesPodQuery(pod, query) {
+		if !fieldSelector.Matches(podFields(pod)) {
 			continue
 		}
 
-		filtered = append(filtered, toPodSummary(pod))
+		summary := toPodSummary(pod)
+		if statusFilter != "" && statusFilter != "all" && !matchesPodStatus(pod, summary.Status, statusFilter) {
+			continue
+		}
+		summary.Usage = usage(pod)
+		filtered = append(filtered, summary)
 	}
-	total := len(filtered)
-	start, end := paginate(total, opts.Offset, opts.Limit)
-	return filtered[start:end], total, nil
+	return filtered, nil
 }
 
-func (s *Service) ListNodes(ctx context.Context) ([]NodeSummary, error) {
-	nodes, err := s.nodes.List(labels.Everything())
+// ListNodes returns every node matching opts' selectors, see ListPods.
+func (s *Service) ListNodes(ctx context.Context, opts ListOptions) ([]NodeSummary, error) {
+	labelSelector, fieldSelector, err := listSelectors(opts, nodeFields(&corev1.Node{}))
+	if err != nil {
+		return nil, err
+	}
+	lister, err := s.nodeLister(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	nodes, err := lister.List(labelSelector)
 	if err != nil {
 		return nil, fmt.Errorf("list nodes: %w", err)
 	}
@@ -103,20 +214,35 @@ func (s *Service) ListNodes(ctx context.Context) ([]NodeSummary, error) {
 		return nil, err
 	}
 
+	usage := s.nodeUsageLookup(ctx)
 	out := make([]NodeSummary, 0, len(nodes))
 	for _, node := range nodes {
-		out = append(out, toNodeSummary(node))
+		if !fieldSelector.Matches(nodeFields(node)) {
+			continue
+		}
+		summary := toNodeSummary(node)
+		summary.Allocated = s.nodeAllocation(ctx, node)
+		summary.Usage = usage(node)
+		out = append(out, summary)
 	}
 	return out, nil
 }
 
-func (s *Service) ListDeployments(ctx context.Context, namespace string) ([]DeploymentSummary, error) {
+// ListDeployments returns every deployment matching opts, see ListPods.
+func (s *Service) ListDeployments(ctx context.Context, opts ListOptions) ([]DeploymentSummary, error) {
+	labelSelector, fieldSelector, err := listSelectors(opts, deploymentFields(&appsv1.Deployment{}))
+	if err != nil {
+		return nil, err
+	}
+	lister, err := s.deploymentLister(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
 	var deployments []*appsv1.Deployment
-	var err error
-	if namespace != "" && namespace != "all" {
-		deployments, err = s.deployments.Deployments(namespace).List(labels.Everything())
+	if opts.Namespace != "" && opts.Namespace != "all" {
+		deployments, err = lister.Deployments(opts.Namespace).List(labelSelector)
 	} else {
-		deployments, err = s.deployments.List(labels.Everything())
+		deployments, err = lister.List(labelSelector)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("list deployments: %w", err)
@@ -128,13 +254,33 @@ func (s *Service) ListDeployments(ctx context.Context, namespace string) ([]Depl
 
 	out := make([]DeploymentSummary, 0, len(deployments))
 	for _, deploy := range deployments {
-		out = append(out, toDeploymentSummary(deploy))
+		if fieldSelector.Matches(deploymentFields(deploy)) {
+			out = append(out, toDeploymentSummary(deploy))
+		}
 	}
 	return out, nil
 }
 
-func (s *Service) ListNamespaces(ctx context.Context) ([]NamespaceSummary, error) {
-	namespaces, err := s.namespaces.List(labels.Everything())
+// ListNamespaces lists cached namespaces matching opts' selectors. In
+// namespace-scoped mode only the watched namespaces are listed, by name alone
+// if namespaces can't be read.
+func (s *Service) ListNamespaces(ctx context.Context, opts ListOptions) ([]NamespaceSummary, error) {
+	labelSelector, fieldSelector, err := listSelectors(opts, namespaceFields(&corev1.Namespace{}))
+	if err != nil {
+		return nil, err
+	}
+	lister, err := s.namespaceLister(ctx)
+	if errors.Is(err, ErrNotPermitted) && len(s.scope) > 0 {
+		out := make([]NamespaceSummary, 0, len(s.scope))
+		for _, name := range s.scope {
+			out = append(out, NamespaceSummary{Name: name})
+		}
+		return out, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list namespaces: %w", err)
+	}
+	namespaces, err := lister.List(labelSelector)
 	if err != nil {
 		return nil, fmt.Errorf("list namespaces: %w", err)
 	}
@@ -145,57 +291,127 @@ func (s *Service) ListNamespaces(ctx context.Context) ([]NamespaceSummary, error
 
 	out := make([]NamespaceSummary, 0, len(namespaces))
 	for _, ns := range namespaces {
-		out = append(out, NamespaceSummary{
-			Name:   ns.Name,
-			Status: string(ns.Status.Phase),
-			Age:    s.defaultSince(ns.CreationTimestamp.Time),
-		})
+		if len(s.scope) > 0 && !slices.Contains(s.scope, ns.Name) {
+			continue
+		}
+		if !fieldSelector.Matches(namespaceFields(ns)) {
+			continue
+		}
+		out = append(out, s.toNamespaceSummary(ns))
 	}
 	return out, nil
 }
 
+// listSelectors parses the label and field selectors of opts; supported lists
+// the fields the resource can be selected on.
+func listSelectors(opts ListOptions, supported fields.Set) (labels.Selector, fields.Selector, error) {
+	labelSelector := labels.Everything()
+	if opts.LabelSelector != "" {
+		parsed, err := labels.Parse(opts.LabelSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: label selector: %v", ErrInvalidQuery, err)
+		}
+		labelSelector = parsed
+	}
+	fieldSelector, err := parseFieldSelector(opts.FieldSelector, supported)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labelSelector, fieldSelector, nil
+}
+
+func (s *Service) toNamespaceSummary(ns *corev1.Namespace) NamespaceSummary {
+	return NamespaceSummary{
+		Name:   ns.Name,
+		Status: string(ns.Status.Phase),
+		Age:    s.defaultSince(ns.CreationTimestamp.Time),
+	}
+}
+
 func (s *Service) GetPod(ctx context.Context, namespace, name string) (PodDetail, error) {
-	pod, err := s.pods.Pods(namespace).Get(name)
+	podLister, err := s.podLister(ctx)
 	if err != nil {
 		return PodDetail{}, fmt.Errorf("get pod: %w", err)
 	}
-	events, _ := s.events.Events(namespace).List(labels.Everything())
-	filteredEvents := make([]EventSummary, 0)
-	for _, ev := range events {
-		if ev.InvolvedObject.Name != name {
-			continue
+	pod, err := podLister.Pods(namespace).Get(name)
+	if err != nil {
+		return PodDetail{}, fmt.Errorf("get pod: %w", err)
+	}
+	// Match by UID: a Deployment or Service may share the pod's name.
+	events, _ := s.byIndex(ctx, "events", indexByInvolvedObject, string(pod.UID))
+	filteredEvents := make([]EventSummary, 0, len(events))
+	for _, obj := range events {
+		if ev, ok := obj.(*corev1.Event); ok {
+			filteredEvents = append(filteredEvents, toEventSummary(ev))
 		}
-		filteredEvents = append(filteredEvents, toEventSummary(ev))
 	}
 
+	summary := toPodSummary(pod)
+	summary.Usage = s.podUsageLookup(ctx)(pod)
 	return PodDetail{
-		PodSummary: toPodSummary(pod),
+		PodSummary: summary,
 		Containers: toContainerStatuses(pod),
 		Conditions: toPodConditions(pod),
 		Events:     filteredEvents,
 		NodeIP:     pod.Status.HostIP,
-		PodIP:      pod.Status.PodIP,
 	}, nil
 }
 
 func (s *Service) GetNode(ctx context.Context, name string) (NodeDetail, error) {
-	node, err := s.nodes.Get(name)
+	lister, err := s.nodeLister(ctx)
+	if err != nil {
+		return NodeDetail{}, fmt.Errorf("get node: %w", err)
+	}
+	node, err := lister.Get(name)
 	if err != nil {
 		return NodeDetail{}, fmt.Errorf("get node: %w", err)
 	}
+	summary := toNodeSummary(node)
+	summary.Allocated = s.nodeAllocation(ctx, node)
+	summary.Usage = s.nodeUsageLookup(ctx)(node)
 	return NodeDetail{
-		NodeSummary: toNodeSummary(node),
+		NodeSummary: summary,
 		Conditions:  toNodeConditions(node),
 		Capacity:    quantityMap(node.Status.Capacity),
 		Allocatable: quantityMap(node.Status.Allocatable),
+		Pods:        s.podsOnNode(ctx, node.Name),
 	}, nil
 }
 
+// podsOnNode lists the cached pods scheduled to node, sorted by namespace and
+// name.
+func (s *Service) podsOnNode(ctx context.Context, node string) []PodSummary {
+	objs, err := s.byIndex(ctx, "pods", indexByNode, node)
+	if err != nil {
+		return []PodSummary{}
+	}
+	usage := s.podUsageLookup(ctx)
+	out := make([]PodSummary, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			summary := toPodSummary(pod)
+			summary.Usage = usage(pod)
+			out = append(out, summary)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
 func (s *Service) GetDeployment(ctx context.Context, namespace, name string) (DeploymentDetail, error) {
 	if namespace == "" || namespace == "all" {
 		return DeploymentDetail{}, fmt.Errorf("namespace required")
 	}
-	deploy, err := s.deployments.Deployments(namespace).Get(name)
+	lister, err := s.deploymentLister(ctx)
+	if err != nil {
+		return DeploymentDetail{}, fmt.Errorf("get deployment: %w", err)
+	}
+	deploy, err := lister.Deployments(namespace).Get(name)
 	if err != nil {
 		return DeploymentDetail{}, fmt.Errorf("get deployment: %w", err)
 	}
@@ -205,20 +421,30 @@ func (s *Service) GetDeployment(ctx context.Context, namespace, name string) (De
 	}, nil
 }
 
-func (s *Service) ListEvents(ctx context.Context, namespace string) ([]EventSummary, error) {
+// ListEvents returns every event matching opts, see ListPods.
+func (s *Service) ListEvents(ctx context.Context, opts ListOptions) ([]EventSummary, error) {
+	labelSelector, fieldSelector, err := listSelectors(opts, eventFields(&corev1.Event{}))
+	if err != nil {
+		return nil, err
+	}
+	lister, err := s.eventLister(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
 	var evts []*corev1.Event
-	var err error
-	if namespace != "" && namespace != "all" {
-		evts, err = s.events.Events(namespace).List(labels.Everything())
+	if opts.Namespace != "" && opts.Namespace != "all" {
+		evts, err = lister.Events(opts.Namespace).List(labelSelector)
 	} else {
-		evts, err = s.events.List(labels.Everything())
+		evts, err = lister.List(labelSelector)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("list events: %w", err)
 	}
 	out := make([]EventSummary, 0, len(evts))
 	for _, ev := range evts {
-		out = append(out, toEventSummary(ev))
+		if fieldSelector.Matches(eventFields(ev)) {
+			out = append(out, toEventSummary(ev))
+		}
 	}
 	return out, nil
 }
@@ -252,26 +478,51 @@ func matchesPodQuery(pod *corev1.Pod, query string) bool {
 }
 
 func toPodSummary(pod *corev1.Pod) PodSummary {
-	return PodSummary{
+	summary := PodSummary{
 		ID:                string(pod.UID),
 		Name:              pod.Name,
 		Namespace:         pod.Namespace,
-		Status:            string(pod.Status.Phase),
+		Status:            podDisplayStatus(pod),
+		Phase:             string(pod.Status.Phase),
+		Ready:             podReadyCount(pod),
 		Restarts:          totalRestarts(pod),
+		LastRestart:       lastRestart(pod),
 		NodeName:          pod.Spec.NodeName,
+		PodIP:             pod.Status.PodIP,
+		QOSClass:          string(pod.Status.QOSClass),
+		Owner:             podOwner(pod),
 		CreationTimestamp: pod.CreationTimestamp.Time,
 		Labels:            pod.Labels,
 	}
+	if summary.LastRestart != nil {
+		summary.LastRestartAge = humanizeDuration(time.Since(*summary.LastRestart))
+	}
+	return summary
 }
 
 func toNodeSummary(node *corev1.Node) NodeSummary {
+	info := node.Status.NodeInfo
+	zone, region := nodeTopology(node)
 	return NodeSummary{
-		Name:           node.Name,
-		Status:         nodeReadyStatus(node),
-		Version:        node.Status.NodeInfo.KubeletVersion,
-		Pods:           int(node.Status.Capacity.Pods().Value()),
-		CPUCapacity:    node.Status.Capacity.Cpu().String(),
-		MemoryCapacity: node.Status.Capacity.Memory().String(),
+		Name:              node.Name,
+		Status:            nodeReadyStatus(node),
+		Roles:             nodeRoles(node),
+		Version:           info.KubeletVersion,
+		InternalIP:        nodeAddress(node, corev1.NodeInternalIP),
+		ExternalIP:        nodeAddress(node, corev1.NodeExternalIP),
+		OSImage:           info.OSImage,
+		KernelVersion:     info.KernelVersion,
+		ContainerRuntime:  info.ContainerRuntimeVersion,
+		Architecture:      info.Architecture,
+		Zone:              zone,
+		Region:            region,
+		Unschedulable:     node.Spec.Unschedulable,
+		Taints:            toNodeTaints(node),
+		CreationTimestamp: node.CreationTimestamp.Time,
+		Age:               humanizeDuration(time.Since(node.CreationTimestamp.Time)),
+		Pods:              int(node.Status.Capacity.Pods().Value()),
+		CPUCapacity:       node.Status.Capacity.Cpu().String(),
+		MemoryCapacity:    node.Status.Capacity.Memory().String(),
 	}
 }
 
@@ -285,14 +536,6 @@ func toDeploymentSummary(deploy *appsv1.Deployment) DeploymentSummary {
 	}
 }
 
-func totalRestarts(pod *corev1.Pod) int32 {
-	var restarts int32
-	for _, status := range pod.Status.ContainerStatuses {
-		restarts += status.RestartCount
-	}
-	return restarts
-}
-
 func nodeReadyStatus(node *corev1.Node) string {
 	for _, cond := range node.Status.Conditions {
 		if cond.Type == corev1.NodeReady {
@@ -415,6 +658,8 @@ func toDeploymentConditions(deploy *appsv1.Deployment) []DeploymentCondition {
 
 func toEventSummary(ev *corev1.Event) EventSummary {
 	return EventSummary{
+		Name:           ev.Name,
+		Namespace:      ev.Namespace,
 		Type:           ev.Type,
 		Reason:         ev.Reason,
 		Message:        ev.Message,
@@ -431,20 +676,3 @@ func quantityMap(resources corev1.ResourceList) map[string]string {
 	}
 	return out
 }
-
-func paginate(total, offset, limit int) (int, int) {
-	if limit <= 0 || limit > 1000 {
-		limit = 200
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	if offset > total {
-		offset = total
-	}
-	end := offset + limit
-	if end > total {
-		end = total
-	}
-	return offset, end
-}
@@ -2,11 +2,13 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
@@ -21,20 +23,65 @@ func TestListPodsWithFiltersAndPagination(t *testing.T) {
 
 	service := newTestService(t, pods, nil, nil, nil)
 
-	items, total, err := service.ListPods(context.Background(), ListOptions{
+	opts := ListOptions{
 		Namespace: "default",
 		Status:    "running",
 		Limit:     1,
 		Offset:    0,
-	})
+	}
+	items, err := service.ListPods(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("list pods: %v", err)
 	}
-	if total != 1 {
-		t.Fatalf("expected total 1, got %d", total)
+	page, err := Page(items, opts)
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+	if page.Count != 1 || page.Continue != "" {
+		t.Fatalf("expected a single page of 1, got %d %q", page.Count, page.Continue)
+	}
+	if len(page.Items) != 1 || page.Items[0].Name != "api-1" {
+		t.Fatalf("unexpected items: %#v", page.Items)
+	}
+
+	if _, err := service.ListPods(context.Background(), ListOptions{FieldSelector: "spec.hostname=x"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected unsupported field selector to fail, got %v", err)
+	}
+}
+
+func TestPageFollowsContinueTokens(t *testing.T) {
+	items := []PodSummary{
+		{Name: "a", Namespace: "default", Restarts: 2},
+		{Name: "b", Namespace: "default", Restarts: 5},
+		{Name: "c", Namespace: "default", Restarts: 2},
 	}
-	if len(items) != 1 || items[0].Name != "api-1" {
-		t.Fatalf("unexpected items: %#v", items)
+	opts := ListOptions{Sort: "restarts", Order: OrderDesc, Limit: 2}
+	first, err := Page(items, opts)
+	if err != nil || first.Count != 3 || first.Continue == "" {
+		t.Fatalf("unexpected first page: %+v %v", first, err)
+	}
+	if first.Items[0].Name != "b" || first.Items[1].Name != "c" {
+		t.Fatalf("expected restarts desc with name ties desc, got %+v", first.Items)
+	}
+
+	// Removing an item already seen must not shift the next page.
+	opts.Continue = first.Continue
+	second, err := Page([]PodSummary{items[0], items[2]}, opts)
+	if err != nil || len(second.Items) != 1 || second.Items[0].Name != "a" || second.Continue != "" {
+		t.Fatalf("unexpected second page: %+v %v", second, err)
+	}
+
+	opts.Sort = "name"
+	if _, err := Page(items, opts); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected the token to be rejected for another sort, got %v", err)
+	}
+	if _, err := Page(items, ListOptions{Sort: "labels"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected unsortable field to fail, got %v", err)
+	}
+
+	projected, err := Project(first.Items, []string{"name", "usage.cpuMillicores"})
+	if err != nil || len(projected) != 2 || len(projected[0]) != 1 || projected[0]["name"] != "b" {
+		t.Fatalf("unexpected projection: %+v %v", projected, err)
 	}
 }
 
@@ -64,26 +111,85 @@ func TestGetPodIncludesEvents(t *testing.T) {
 			Kind:      "Pod",
 			Name:      "api-1",
 			Namespace: "default",
+			UID:       "1",
 		},
 		Reason:        "Started",
 		Message:       "Pod started",
 		Count:         1,
 		LastTimestamp: metav1.Time{Time: time.Now()},
 	}
+	// Same name, different object.
+	other := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "ev2", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Service", Name: "api-1", Namespace: "default", UID: "2"},
+		Reason:         "Created",
+	}
 
-	service := newTestService(t, []*corev1.Pod{pod}, nil, nil, []*corev1.Event{event})
+	service := newTestService(t, []*corev1.Pod{pod}, nil, nil, []*corev1.Event{event, other})
 	detail, err := service.GetPod(context.Background(), "default", "api-1")
 	if err != nil {
 		t.Fatalf("get pod: %v", err)
 	}
-	if len(detail.Events) != 1 {
-		t.Fatalf("expected events to be included")
+	if len(detail.Events) != 1 || detail.Events[0].Reason != "Started" {
+		t.Fatalf("expected only the pod's events, got %+v", detail.Events)
 	}
 	if detail.PodIP != "10.0.0.10" {
 		t.Fatalf("expected pod IP to be set")
 	}
 }
 
+func TestGetNodeSummarizesAllocation(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{
+			"node-role.kubernetes.io/control-plane": "",
+			corev1.LabelTopologyZone:                "eu-west-1a",
+		}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}}},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			Addresses:   []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+		},
+	}
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}
+	}
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:       "node-1",
+				InitContainers: []corev1.Container{{Name: "migrate", Resources: requests("1", "256Mi")}},
+				Containers:     []corev1.Container{{Name: "app", Resources: requests("500m", "1Gi")}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job-1", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "job", Resources: requests("1", "1Gi")}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}
+	svc := newTestService(t, pods, []*corev1.Node{node}, nil, nil)
+
+	detail, err := svc.GetNode(context.Background(), "node-1")
+	if err != nil {
+		t.Fatalf("get node: %v", err)
+	}
+	if len(detail.Roles) != 1 || detail.Roles[0] != "control-plane" || detail.Zone != "eu-west-1a" || detail.InternalIP != "10.0.0.1" || len(detail.Taints) != 1 {
+		t.Fatalf("unexpected node summary %+v", detail.NodeSummary)
+	}
+	alloc := detail.Allocated
+	if alloc == nil || alloc.Pods != 1 || alloc.CPURequests != 1000 || alloc.MemoryRequests != 1<<30 || *alloc.CPURequestPercent != 50 {
+		t.Fatalf("expected the running pod's effective requests, got %+v", alloc)
+	}
+	if len(detail.Pods) != 2 {
+		t.Fatalf("expected both pods on the node, got %d", len(detail.Pods))
+	}
+}
+
 func newTestService(t *testing.T, pods []*corev1.Pod, nodes []*corev1.Node, deployments []*appsv1.Deployment, events []*corev1.Event) *Service {
 	t.Helper()
 	client := fake.NewSimpleClientset()
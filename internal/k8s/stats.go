@@ -0,0 +1,255 @@
+package k8s
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerStat describes the cache of one resource type in one cluster.
+type InformerStat struct {
+	Cluster   string
+	Resource  string
+	Objects   int
+	Synced    bool
+	LastEvent time.Time // last change received from the API server
+	LastSync  time.Time // last successful list, i.e. initial sync or relist
+	// CacheAge is the time since the cache was last known to be current:
+	// a change arrived or its watch was (re)established.
+	CacheAge       time.Duration
+	WatchErrors    int64
+	LastWatchError string
+}
+
+// CacheUsage estimates the memory one resource type's cache takes, see
+// informerSet.bytes.
+type CacheUsage struct {
+	Cluster  string `json:"cluster"`
+	Resource string `json:"resource"`
+	Objects  int    `json:"objects"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// informerActivity records when an informer last heard from the API server.
+// Periodic resyncs replay the local cache and don't count.
+type informerActivity struct {
+	lastEvent   atomic.Int64 // unix nanoseconds
+	lastWatch   atomic.Int64
+	lastList    atomic.Int64
+	watchErrors atomic.Int64
+
+	mu      sync.Mutex
+	lastErr string
+}
+
+func newInformerActivity() *informerActivity {
+	a := &informerActivity{}
+	// Count from creation so a watch that never comes up still ages.
+	a.lastWatch.Store(time.Now().UnixNano())
+	return a
+}
+
+func (a *informerActivity) handler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(any) { a.touch() },
+		UpdateFunc: func(oldObj, newObj any) {
+			oldMeta, err1 := meta.Accessor(oldObj)
+			newMeta, err2 := meta.Accessor(newObj)
+			if err1 == nil && err2 == nil && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+			a.touch()
+		},
+		DeleteFunc: func(any) { a.touch() },
+	}
+}
+
+func (a *informerActivity) touch() {
+	a.lastEvent.Store(time.Now().UnixNano())
+}
+
+// watched records a successfully established watch.
+func (a *informerActivity) watched() {
+	a.lastWatch.Store(time.Now().UnixNano())
+}
+
+// listed records a successful list, which replaces the cache's contents.
+func (a *informerActivity) listed() {
+	a.lastList.Store(time.Now().UnixNano())
+}
+
+// watchError records a failed list or watch; the reflector retries on its own.
+func (a *informerActivity) watchError(_ *cache.Reflector, err error) {
+	a.watchErrors.Add(1)
+	a.mu.Lock()
+	a.lastErr = err.Error()
+	a.mu.Unlock()
+}
+
+func (a *informerActivity) last() time.Time {
+	return unixTime(a.lastEvent.Load())
+}
+
+// age is the time since the informer was last known to be current.
+func (a *informerActivity) age(now time.Time) time.Duration {
+	return now.Sub(unixTime(max(a.lastEvent.Load(), a.lastWatch.Load())))
+}
+
+func unixTime(ns int64) time.Time {
+	if ns > 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// activityTracker holds the activity of each resource type of a cluster. The
+// transport looks entries up by the resource in the request path.
+type activityTracker struct {
+	mu         sync.Mutex
+	byResource map[string]*informerActivity
+}
+
+func newActivityTracker() *activityTracker {
+	return &activityTracker{byResource: make(map[string]*informerActivity)}
+}
+
+// lookup returns the activity of resource if an informer registered it.
+func (t *activityTracker) lookup(resource string) (*informerActivity, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.byResource[resource]
+	return a, ok
+}
+
+func (t *activityTracker) get(resource string) *informerActivity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.byResource[resource]
+	if !ok {
+		a = newInformerActivity()
+		t.byResource[resource] = a
+	}
+	return a
+}
+
+// InformerStats reports every informer of the cluster, sorted by resource.
+func (c *Cluster) InformerStats() []InformerStat {
+	if c.pool == nil {
+		return nil
+	}
+	now := time.Now()
+	running := c.pool.snapshot()
+	out := make([]InformerStat, 0, len(running))
+	for resource, set := range running {
+		activity := c.activity.get(resource)
+		activity.mu.Lock()
+		lastErr := activity.lastErr
+		activity.mu.Unlock()
+		out = append(out, InformerStat{
+			Resource:       resource,
+			Objects:        set.objects(),
+			Synced:         set.HasSynced(),
+			LastEvent:      activity.last(),
+			LastSync:       unixTime(activity.lastList.Load()),
+			CacheAge:       activity.age(now),
+			WatchErrors:    activity.watchErrors.Load(),
+			LastWatchError: lastErr,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Resource < out[j].Resource })
+	return out
+}
+
+// CacheAge reports how stale the cache of resource may be, see InformerStat.
+// It is unknown for resources without running informers.
+func (c *Cluster) CacheAge(resource string) (time.Duration, bool) {
+	if c.pool == nil {
+		return 0, false
+	}
+	if _, ok := c.pool.snapshot()[resource]; !ok {
+		return 0, false
+	}
+	return c.activity.get(resource).age(time.Now()), true
+}
+
+// staleResources lists the synced informers whose cache is older than
+// threshold.
+func (c *Cluster) staleResources(threshold time.Duration) []string {
+	var stale []string
+	for _, stat := range c.InformerStats() {
+		if stat.Synced && stat.CacheAge > threshold {
+			stale = append(stale, stat.Resource)
+		}
+	}
+	return stale
+}
+
+// InformerStats reports the informers of every started cluster.
+func (r *Registry) InformerStats() []InformerStat {
+	var out []InformerStat
+	for name, cluster := range r.startedClusters() {
+		for _, stat := range cluster.InformerStats() {
+			stat.Cluster = name
+			out = append(out, stat)
+		}
+	}
+	return out
+}
+
+// startedClusters returns the clusters whose start attempt finished, by name.
+func (r *Registry) startedClusters() map[string]*Cluster {
+	r.mu.RLock()
+	entries := make([]*registryEntry, 0, len(r.clusters))
+	for _, entry := range r.clusters {
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	out := make(map[string]*Cluster, len(entries))
+	for _, entry := range entries {
+		entry.mu.Lock()
+		cluster, synced := entry.cluster, entry.synced
+		entry.mu.Unlock()
+		if cluster != nil && synced {
+			out[entry.name] = cluster
+		}
+	}
+	return out
+}
+
+// CacheUsage estimates the memory of every running cache, sorted by
+// resource. It walks the caches, so it's meant for occasional inspection.
+func (c *Cluster) CacheUsage() []CacheUsage {
+	if c.pool == nil {
+		return nil
+	}
+	running := c.pool.snapshot()
+	out := make([]CacheUsage, 0, len(running))
+	for resource, set := range running {
+		out = append(out, CacheUsage{Resource: resource, Objects: set.objects(), Bytes: set.bytes()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Resource < out[j].Resource })
+	return out
+}
+
+// CacheUsage reports the caches of every started cluster.
+func (r *Registry) CacheUsage() []CacheUsage {
+	var out []CacheUsage
+	for name, cluster := range r.startedClusters() {
+		for _, usage := range cluster.CacheUsage() {
+			usage.Cluster = name
+			out = append(out, usage)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cluster != out[j].Cluster {
+			return out[i].Cluster < out[j].Cluster
+		}
+		return out[i].Resource < out[j].Resource
+	})
+	return out
+}
@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInformerActivityIgnoresResync(t *testing.T) {
+	var activity informerActivity
+	handler := activity.handler()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", ResourceVersion: "1"}}
+
+	handler.OnUpdate(pod, pod)
+	if !activity.last().IsZero() {
+		t.Fatal("resync counted as an event")
+	}
+
+	changed := pod.DeepCopy()
+	changed.ResourceVersion = "2"
+	handler.OnUpdate(pod, changed)
+	if activity.last().IsZero() {
+		t.Fatal("update not recorded")
+	}
+}
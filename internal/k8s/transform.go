@@ -6,25 +6,63 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// stripHeavyFields removes rarely-used fields to shrink payloads cached by informers.
-func stripHeavyFields(obj any) (any, error) {
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// CacheTrim selects what the informer transform drops on top of
+// managedFields, which is always removed.
+type CacheTrim struct {
+	// LastApplied drops kubectl's last-applied-configuration annotation, a
+	// full copy of the applied manifest.
+	LastApplied bool
+	// EnvOver drops the env of pod and ReplicaSet containers once it has
+	// more entries than this; zero keeps env. Deployments keep theirs since
+	// workload diffs compare it.
+	EnvOver int
+	// SecretData drops Secret values and keeps their keys. No Secret
+	// informer is registered yet; it applies once one is.
+	SecretData bool
+}
+
+// transform removes rarely-used fields to shrink payloads cached by informers.
+func (t CacheTrim) transform(obj any) (any, error) {
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return obj, nil
+	}
+	accessor.SetManagedFields(nil)
+	if t.LastApplied {
+		if annotations := accessor.GetAnnotations(); annotations != nil {
+			delete(annotations, lastAppliedAnnotation)
+		}
+	}
+
 	switch v := obj.(type) {
 	case *corev1.Pod:
-		v.ManagedFields = nil
-		return v, nil
-	case *corev1.Node:
-		v.ManagedFields = nil
-		return v, nil
-	case *corev1.Namespace:
-		v.ManagedFields = nil
-		return v, nil
-	case *appsv1.Deployment:
-		v.ManagedFields = nil
-		return v, nil
-	default:
-		if accessor, ok := obj.(metav1.Object); ok {
-			accessor.SetManagedFields(nil)
+		t.trimPodSpec(&v.Spec)
+	case *appsv1.ReplicaSet:
+		t.trimPodSpec(&v.Spec.Template.Spec)
+	case *corev1.Secret:
+		if t.SecretData {
+			for key := range v.Data {
+				v.Data[key] = nil
+			}
+			for key := range v.StringData {
+				v.StringData[key] = ""
+			}
+		}
+	}
+	return obj, nil
+}
+
+func (t CacheTrim) trimPodSpec(spec *corev1.PodSpec) {
+	if t.EnvOver <= 0 {
+		return
+	}
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			if len(containers[i].Env) > t.EnvOver {
+				containers[i].Env = nil
+			}
 		}
-		return obj, nil
 	}
 }
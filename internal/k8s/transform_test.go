@@ -0,0 +1,57 @@
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCacheTrim(t *testing.T) {
+	env := []corev1.EnvVar{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "api",
+			Annotations:   map[string]string{lastAppliedAnnotation: "{}", "team": "a"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "big", Env: env}, {Name: "small", Env: env[:1]}}},
+	}
+	trim := CacheTrim{LastApplied: true, EnvOver: 2}
+	if _, err := trim.transform(pod); err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if pod.ManagedFields != nil || len(pod.Annotations) != 1 || pod.Annotations["team"] != "a" {
+		t.Fatalf("unexpected metadata: %+v", pod.ObjectMeta)
+	}
+	if pod.Spec.Containers[0].Env != nil || len(pod.Spec.Containers[1].Env) != 1 {
+		t.Fatalf("expected only the large env dropped: %+v", pod.Spec.Containers)
+	}
+
+	// Workload diffs compare deployment env, so it stays.
+	deploy := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "big", Env: env}}},
+	}}}
+	if _, err := trim.transform(deploy); err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if len(deploy.Spec.Template.Spec.Containers[0].Env) != 3 {
+		t.Fatalf("expected deployment env to be kept")
+	}
+
+	secret := &corev1.Secret{Data: map[string][]byte{"password": []byte("hunter2"), "user": []byte("admin")}}
+	if _, err := trim.transform(secret); err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if string(secret.Data["password"]) != "hunter2" {
+		t.Fatalf("expected secret data kept without SecretData")
+	}
+	trim.SecretData = true
+	if _, err := trim.transform(secret); err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if len(secret.Data) != 2 || secret.Data["password"] != nil || secret.Data["user"] != nil {
+		t.Fatalf("expected only secret keys kept: %+v", secret.Data)
+	}
+}
@@ -2,34 +2,86 @@ package k8s
 
 import "time"
 
-// ListOptions captures common list filters.
+// ListOptions captures common list filters and the paging applied by Page.
 type ListOptions struct {
 	Namespace     string
 	Status        string
 	LabelSelector string
+	FieldSelector string // e.g. "spec.nodeName=node-1", see selectors.go
 	Query         string
+	Sort          string // JSON field, dotted for nested ones
+	Order         string // OrderAsc (default) or OrderDesc
 	Limit         int
-	Offset        int
+	Offset        int    // ignored with Continue
+	Continue      string // token from the previous page
 }
 
 type PodSummary struct {
 	ID                string            `json:"id"`
+	Cluster           string            `json:"cluster,omitempty"`
 	Name              string            `json:"name"`
 	Namespace         string            `json:"namespace"`
-	Status            string            `json:"status"`
+	Status            string            `json:"status"` // as shown by kubectl, e.g. CrashLoopBackOff
+	Phase             string            `json:"phase"`
+	Ready             string            `json:"ready"` // ready/total containers, e.g. "2/3"
 	Restarts          int32             `json:"restarts"`
+	LastRestart       *time.Time        `json:"lastRestart,omitempty"`
+	LastRestartAge    string            `json:"lastRestartAge,omitempty"`
 	NodeName          string            `json:"nodeName,omitempty"`
+	PodIP             string            `json:"podIP,omitempty"`
+	QOSClass          string            `json:"qosClass,omitempty"`
+	Owner             *OwnerReference   `json:"owner,omitempty"` // controller, e.g. a ReplicaSet
 	CreationTimestamp time.Time         `json:"creationTimestamp"`
 	Labels            map[string]string `json:"labels,omitempty"`
+	Usage             *PodUsage         `json:"usage,omitempty"`
+}
+
+type OwnerReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
 }
 
 type NodeSummary struct {
-	Name           string `json:"name"`
-	Status         string `json:"status"`
-	Version        string `json:"version"`
-	Pods           int    `json:"pods"`
-	CPUCapacity    string `json:"cpuCapacity"`
-	MemoryCapacity string `json:"memoryCapacity"`
+	Name              string          `json:"name"`
+	Cluster           string          `json:"cluster,omitempty"`
+	Status            string          `json:"status"`
+	Roles             []string        `json:"roles"`
+	Version           string          `json:"version"`
+	InternalIP        string          `json:"internalIP,omitempty"`
+	ExternalIP        string          `json:"externalIP,omitempty"`
+	OSImage           string          `json:"osImage,omitempty"`
+	KernelVersion     string          `json:"kernelVersion,omitempty"`
+	ContainerRuntime  string          `json:"containerRuntime,omitempty"`
+	Architecture      string          `json:"architecture,omitempty"`
+	Zone              string          `json:"zone,omitempty"`
+	Region            string          `json:"region,omitempty"`
+	Unschedulable     bool            `json:"unschedulable"`
+	Taints            []NodeTaint     `json:"taints,omitempty"`
+	CreationTimestamp time.Time       `json:"creationTimestamp"`
+	Age               string          `json:"age"`
+	Pods              int             `json:"pods"` // pod capacity
+	CPUCapacity       string          `json:"cpuCapacity"`
+	MemoryCapacity    string          `json:"memoryCapacity"`
+	Allocated         *NodeAllocation `json:"allocated,omitempty"`
+	Usage             *NodeUsage      `json:"usage,omitempty"`
+}
+
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// NodeAllocation is what the node's non-terminated pods request, against
+// what it can allocate. CPU is in millicores, memory in bytes.
+type NodeAllocation struct {
+	Pods                 int      `json:"pods"`
+	CPURequests          int64    `json:"cpuRequests"`
+	CPUAllocatable       int64    `json:"cpuAllocatable"`
+	CPURequestPercent    *float64 `json:"cpuRequestPercent,omitempty"`
+	MemoryRequests       int64    `json:"memoryRequests"`
+	MemoryAllocatable    int64    `json:"memoryAllocatable"`
+	MemoryRequestPercent *float64 `json:"memoryRequestPercent,omitempty"`
 }
 
 type DeploymentSummary struct {
@@ -49,7 +101,25 @@ type NamespaceSummary struct {
 // ListResponse is a simple envelope for list endpoints.
 type ListResponse[T any] struct {
 	Items []T `json:"items"`
-	Count int `json:"count"`
+	Count int `json:"count"` // matching items across all pages
+	// Continue fetches the next page; empty on the last one.
+	Continue string `json:"continue,omitempty"`
+	// CacheAge is how many seconds old the cache serving the list may be.
+	CacheAge *int64 `json:"cacheAge,omitempty"`
+}
+
+// ClusterError reports a cluster that could not contribute to a fleet view.
+type ClusterError struct {
+	Cluster string `json:"cluster"`
+	Error   string `json:"error"`
+}
+
+// FleetResponse is the envelope for views merged across clusters.
+type FleetResponse[T any] struct {
+	Items    []T            `json:"items"`
+	Count    int            `json:"count"`
+	Continue string         `json:"continue,omitempty"`
+	Errors   []ClusterError `json:"errors,omitempty"`
 }
 
 type ContainerStatus struct {
@@ -71,6 +141,8 @@ type PodCondition struct {
 }
 
 type EventSummary struct {
+	Name           string    `json:"name"`
+	Namespace      string    `json:"namespace"`
 	Type           string    `json:"type"`
 	Reason         string    `json:"reason"`
 	Message        string    `json:"message"`
@@ -85,7 +157,6 @@ type PodDetail struct {
 	Conditions []PodCondition    `json:"conditions"`
 	Events     []EventSummary    `json:"events,omitempty"`
 	NodeIP     string            `json:"nodeIP,omitempty"`
-	PodIP      string            `json:"podIP,omitempty"`
 }
 
 type NodeCondition struct {
@@ -101,6 +172,7 @@ type NodeDetail struct {
 	Conditions  []NodeCondition   `json:"conditions"`
 	Capacity    map[string]string `json:"capacity"`
 	Allocatable map[string]string `json:"allocatable"`
+	Pods        []PodSummary      `json:"pods"`
 }
 
 type DeploymentCondition struct {
@@ -115,3 +187,32 @@ type DeploymentDetail struct {
 	DeploymentSummary
 	Conditions []DeploymentCondition `json:"conditions"`
 }
+
+// DiffScope identifies one side of a workload diff.
+type DiffScope struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+}
+
+// FieldDiff is a single differing field; an empty side means the field is
+// absent there.
+type FieldDiff struct {
+	Field  string `json:"field"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+type ObjectDiff struct {
+	Kind        string      `json:"kind"`
+	Name        string      `json:"name"`
+	Status      string      `json:"status"`
+	Differences []FieldDiff `json:"differences,omitempty"`
+}
+
+type DiffReport struct {
+	Source DiffScope    `json:"source"`
+	Target DiffScope    `json:"target"`
+	Kinds  []string     `json:"kinds"`
+	Items  []ObjectDiff `json:"items"`
+	Count  int          `json:"count"`
+}
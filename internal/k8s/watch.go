@@ -0,0 +1,320 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	WatchAdded    = "ADDED"
+	WatchModified = "MODIFIED"
+	WatchDeleted  = "DELETED"
+
+	defaultWatchBuffer       = 256
+	defaultSlowClientTimeout = 5 * time.Second
+)
+
+// ErrWatchOverflow ends a subscription whose client didn't keep up. The
+// client should reload its lists and subscribe again.
+var ErrWatchOverflow = errors.New("watch client too slow, events dropped")
+
+// ErrCacheRestarted ends subscriptions whose informers were replaced by the
+// watchdog. The client should reload its lists and subscribe again.
+var ErrCacheRestarted = errors.New("cache restarted, resubscribe")
+
+// WatchEvent is a change to a cached object, carrying the same summary type
+// the list endpoints return.
+type WatchEvent struct {
+	Type   string `json:"type"`
+	Kind   string `json:"kind"`
+	Object any    `json:"object"`
+}
+
+type WatchOptions struct {
+	Kinds     []string // see ParseWatchKinds; empty means all kinds
+	Namespace string   // ignored for cluster-scoped kinds
+	// Initial replays every cached object as ADDED before live changes.
+	Initial bool
+	// Buffer is the number of events queued per client.
+	Buffer int
+	// SlowClientTimeout is how long a full buffer may block the informer's
+	// delivery to this client before the subscription is dropped.
+	SlowClientTimeout time.Duration
+}
+
+type watchKind struct {
+	kind       string
+	namespaced bool
+	resource   string
+	summary    func(s *Service, obj any) (any, bool)
+}
+
+var watchKinds = []watchKind{
+	{
+		kind:       "Pod",
+		namespaced: true,
+		resource:   "pods",
+		summary: func(_ *Service, obj any) (any, bool) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return nil, false
+			}
+			return toPodSummary(pod), true
+		},
+	},
+	{
+		kind:       "Deployment",
+		namespaced: true,
+		resource:   "deployments",
+		summary: func(_ *Service, obj any) (any, bool) {
+			deploy, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return nil, false
+			}
+			return toDeploymentSummary(deploy), true
+		},
+	},
+	{
+		kind:     "Node",
+		resource: "nodes",
+		summary: func(_ *Service, obj any) (any, bool) {
+			node, ok := obj.(*corev1.Node)
+			if !ok {
+				return nil, false
+			}
+			return toNodeSummary(node), true
+		},
+	},
+	{
+		kind:     "Namespace",
+		resource: "namespaces",
+		summary: func(s *Service, obj any) (any, bool) {
+			ns, ok := obj.(*corev1.Namespace)
+			if !ok {
+				return nil, false
+			}
+			return s.toNamespaceSummary(ns), true
+		},
+	},
+	{
+		kind:       "Event",
+		namespaced: true,
+		resource:   "events",
+		summary: func(_ *Service, obj any) (any, bool) {
+			ev, ok := obj.(*corev1.Event)
+			if !ok {
+				return nil, false
+			}
+			return toEventSummary(ev), true
+		},
+	},
+}
+
+// ParseWatchKinds maps a comma separated kinds query (e.g. "pods,deployments")
+// to kind names. Empty means all kinds.
+func ParseWatchKinds(raw string) ([]string, error) {
+	var kinds []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		found := false
+		for _, wk := range watchKinds {
+			name := strings.ToLower(wk.kind)
+			if part == name || part == name+"s" {
+				kinds = append(kinds, wk.kind)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unsupported kind %q", part)
+		}
+	}
+	return kinds, nil
+}
+
+// Subscription delivers watch events to one client until it is stopped, its
+// context ends or it falls behind.
+type Subscription struct {
+	events  chan WatchEvent
+	done    chan struct{}
+	timeout time.Duration
+
+	once sync.Once
+	mu   sync.Mutex
+	err  error
+	regs []func() // handler removals and informer releases
+}
+
+// Events yields changes. The channel is never closed; select on Done too.
+func (s *Subscription) Events() <-chan WatchEvent { return s.events }
+
+// Done is closed when the subscription ends.
+func (s *Subscription) Done() <-chan struct{} { return s.done }
+
+// Err reports why the subscription ended, nil if it was stopped normally.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stop removes the informer handlers and ends the subscription.
+func (s *Subscription) Stop() { s.close(nil) }
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		regs := s.regs
+		s.regs = nil
+		s.mu.Unlock()
+		close(s.done)
+		// RemoveEventHandler doesn't wait for the listener to drain, so this
+		// is safe from within a handler.
+		for _, undo := range regs {
+			undo()
+		}
+	})
+}
+
+// send queues ev, blocking the informer's delivery to this client for at most
+// the slow client timeout. Each handler has its own delivery queue in
+// client-go, so a slow client never stalls other subscribers or the cache.
+func (s *Subscription) send(ev WatchEvent) {
+	select {
+	case s.events <- ev:
+		return
+	case <-s.done:
+		return
+	default:
+	}
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case s.events <- ev:
+	case <-s.done:
+	case <-timer.C:
+		s.close(ErrWatchOverflow)
+	}
+}
+
+// Watch subscribes to changes of the given kinds through informer event
+// handlers. The subscription ends when ctx is done.
+func (s *Service) Watch(ctx context.Context, opts WatchOptions) (*Subscription, error) {
+	selected := watchKinds
+	explicit := len(opts.Kinds) > 0
+	if explicit {
+		selected = nil
+		for _, kind := range opts.Kinds {
+			found := false
+			for _, wk := range watchKinds {
+				if wk.kind == kind {
+					selected = append(selected, wk)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unsupported kind %q", kind)
+			}
+		}
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultWatchBuffer
+	}
+	if opts.SlowClientTimeout <= 0 {
+		opts.SlowClientTimeout = defaultSlowClientTimeout
+	}
+	namespace := opts.Namespace
+	if namespace == "all" {
+		namespace = ""
+	}
+
+	sub := &Subscription{
+		events:  make(chan WatchEvent, opts.Buffer),
+		done:    make(chan struct{}),
+		timeout: opts.SlowClientTimeout,
+	}
+	for _, wk := range selected {
+		set, release, err := s.informers.hold(ctx, wk.resource)
+		if errors.Is(err, ErrNotPermitted) && !explicit {
+			// Only an explicit request for an uncached kind is an error.
+			continue
+		}
+		if err != nil {
+			sub.Stop()
+			return nil, fmt.Errorf("watch %s: %w", wk.kind, err)
+		}
+		remove, err := set.addEventHandler(s.watchHandler(sub, wk, namespace, opts.Initial))
+		if err != nil {
+			release()
+			sub.Stop()
+			return nil, fmt.Errorf("watch %s: %w", wk.kind, err)
+		}
+		sub.mu.Lock()
+		sub.regs = append(sub.regs, remove, release)
+		sub.mu.Unlock()
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Stop()
+		case <-s.stopped:
+			sub.close(ErrCacheRestarted)
+		case <-sub.done:
+		}
+	}()
+	return sub, nil
+}
+
+func (s *Service) watchHandler(sub *Subscription, wk watchKind, namespace string, initial bool) cache.ResourceEventHandler {
+	emit := func(eventType string, obj any) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if wk.namespaced && namespace != "" {
+			accessor, err := meta.Accessor(obj)
+			if err != nil || accessor.GetNamespace() != namespace {
+				return
+			}
+		}
+		summary, ok := wk.summary(s, obj)
+		if !ok {
+			return
+		}
+		sub.send(WatchEvent{Type: eventType, Kind: wk.kind, Object: summary})
+	}
+
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if isInInitialList && !initial {
+				return
+			}
+			emit(WatchAdded, obj)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			// Periodic resyncs deliver unchanged objects; skip them.
+			oldMeta, err1 := meta.Accessor(oldObj)
+			newMeta, err2 := meta.Accessor(newObj)
+			if err1 == nil && err2 == nil && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+			emit(WatchModified, newObj)
+		},
+		DeleteFunc: func(obj any) {
+			emit(WatchDeleted, obj)
+		},
+	}
+}
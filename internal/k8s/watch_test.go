@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newWatchService(t *testing.T, objects ...*corev1.Pod) (*Service, *fake.Clientset) {
+	t.Helper()
+	client := fake.NewSimpleClientset()
+	for _, pod := range objects {
+		_, _ = client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	}
+	factory := informers.NewSharedInformerFactory(client, 0)
+	svc := NewService(client, factory)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return svc, client
+}
+
+func nextWatchEvent(t *testing.T, sub *Subscription) WatchEvent {
+	t.Helper()
+	select {
+	case ev := <-sub.Events():
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for watch event")
+	}
+	return WatchEvent{}
+}
+
+func TestWatchStreamsPodChanges(t *testing.T) {
+	existing := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "team-a"}}
+	svc, client := newWatchService(t, existing)
+
+	sub, err := svc.Watch(context.Background(), WatchOptions{Kinds: []string{"Pod"}, Namespace: "team-a"})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer sub.Stop()
+
+	ctx := context.Background()
+	_, _ = client.CoreV1().Pods("team-b").Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"}}, metav1.CreateOptions{})
+	_, _ = client.CoreV1().Pods("team-a").Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}}, metav1.CreateOptions{})
+
+	ev := nextWatchEvent(t, sub)
+	if ev.Type != WatchAdded || ev.Kind != "Pod" || ev.Object.(PodSummary).Name != "web" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	_ = client.CoreV1().Pods("team-a").Delete(ctx, "web", metav1.DeleteOptions{})
+	ev = nextWatchEvent(t, sub)
+	if ev.Type != WatchDeleted || ev.Object.(PodSummary).Name != "web" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestWatchDropsSlowClient(t *testing.T) {
+	svc, client := newWatchService(t)
+	sub, err := svc.Watch(context.Background(), WatchOptions{
+		Kinds:             []string{"Pod"},
+		Buffer:            1,
+		SlowClientTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, _ = client.CoreV1().Pods("default").Create(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}, metav1.CreateOptions{})
+	}
+
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("slow subscription was not dropped")
+	}
+	if !errors.Is(sub.Err(), ErrWatchOverflow) {
+		t.Fatalf("expected overflow, got %v", sub.Err())
+	}
+}
+
+func TestWatchEndsWhenCacheRestarted(t *testing.T) {
+	svc, _ := newWatchService(t)
+	sub, err := svc.Watch(context.Background(), WatchOptions{Kinds: []string{"Pod"}})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	svc.stop()
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not ended")
+	}
+	if !errors.Is(sub.Err(), ErrCacheRestarted) {
+		t.Fatalf("expected ErrCacheRestarted, got %v", sub.Err())
+	}
+}
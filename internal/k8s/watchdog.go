@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// RunWatchdog restarts the informers of any synced cluster whose cache has
+// been stale for longer than threshold, until ctx is done. Reflectors renew
+// their watch every 5-10 minutes, so a threshold above that only fires when a
+// watch silently broke.
+func (r *Registry) RunWatchdog(ctx context.Context, threshold time.Duration, logger *slog.Logger) {
+	if threshold <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(max(threshold/4, 10*time.Second), time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.restartStale(threshold, logger)
+		}
+	}
+}
+
+func (r *Registry) restartStale(threshold time.Duration, logger *slog.Logger) {
+	r.mu.RLock()
+	entries := make([]*registryEntry, 0, len(r.clusters))
+	for _, entry := range r.clusters {
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	for _, entry := range entries {
+		entry.mu.Lock()
+		cluster, synced := entry.cluster, entry.synced
+		entry.mu.Unlock()
+		if cluster == nil || !synced {
+			continue
+		}
+		stale := cluster.staleResources(threshold)
+		if len(stale) == 0 {
+			continue
+		}
+
+		logger.Warn("informer cache stale, restarting",
+			slog.String("cluster", entry.name),
+			slog.String("resources", strings.Join(stale, ",")))
+		if err := entry.restart(r.ctx, r.cfg.SyncTimeout); err != nil {
+			logger.Error("informer restart failed", slog.String("cluster", entry.name), slog.String("error", err.Error()))
+			continue
+		}
+		logger.Info("informer cache restarted", slog.String("cluster", entry.name))
+	}
+}
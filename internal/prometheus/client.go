@@ -0,0 +1,171 @@
+// Package prometheus queries Prometheus-compatible HTTP APIs (Prometheus,
+// Thanos, Mimir, VictoriaMetrics) for resource charts.
+package prometheus
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubezen/internal/config"
+)
+
+// ErrNotConfigured means no data source exists for the requested cluster.
+var ErrNotConfigured = errors.New("prometheus not configured for this cluster")
+
+// Point is one sample of a series. NaN and infinite samples are dropped.
+type Point struct {
+	T time.Time `json:"t"`
+	V float64   `json:"v"`
+}
+
+// Series is one labelled time series of a range query.
+type Series struct {
+	Labels map[string]string `json:"labels"`
+	Points []Point           `json:"points"`
+}
+
+// Client runs range queries against one Prometheus-compatible endpoint.
+type Client struct {
+	baseURL   string
+	http      *http.Client
+	tokenFile string
+}
+
+func NewClient(baseURL string, cfg config.PrometheusConfig) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		http:      &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		tokenFile: cfg.BearerTokenFile,
+	}
+}
+
+type apiResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange runs query over [start, end] at the given step.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	form := url.Values{}
+	form.Set("query", query)
+	form.Set("start", strconv.FormatInt(start.Unix(), 10))
+	form.Set("end", strconv.FormatInt(end.Unix(), 10))
+	form.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/query_range", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.tokenFile != "" {
+		// Read on every request so rotated tokens are picked up.
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read prometheus token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus query: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("prometheus query: %w", err)
+	}
+
+	var parsed apiResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("prometheus query: unexpected response (HTTP %d)", resp.StatusCode)
+	}
+	if parsed.Status != "success" {
+		return nil, fmt.Errorf("prometheus query: %s: %s", parsed.ErrorType, parsed.Error)
+	}
+	if parsed.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("prometheus query: unexpected result type %q", parsed.Data.ResultType)
+	}
+
+	out := make([]Series, 0, len(parsed.Data.Result))
+	for _, result := range parsed.Data.Result {
+		series := Series{Labels: result.Metric, Points: make([]Point, 0, len(result.Values))}
+		if series.Labels == nil {
+			series.Labels = map[string]string{}
+		}
+		for _, value := range result.Values {
+			ts, ok := value[0].(float64)
+			raw, ok2 := value[1].(string)
+			if !ok || !ok2 {
+				continue
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			sec, frac := math.Modf(ts)
+			series.Points = append(series.Points, Point{T: time.Unix(int64(sec), int64(frac*1e9)).UTC(), V: v})
+		}
+		out = append(out, series)
+	}
+	return out, nil
+}
+
+// Sources maps clusters to their Prometheus data source.
+type Sources struct {
+	fallback *Client
+	clusters map[string]*Client
+}
+
+// NewSources builds a client per configured URL. It returns nil when
+// Prometheus isn't configured.
+func NewSources(cfg config.PrometheusConfig) *Sources {
+	if !cfg.Enabled() {
+		return nil
+	}
+	sources := &Sources{clusters: make(map[string]*Client, len(cfg.ClusterURLs))}
+	if cfg.URL != "" {
+		sources.fallback = NewClient(cfg.URL, cfg)
+	}
+	for cluster, u := range cfg.ClusterURLs {
+		sources.clusters[cluster] = NewClient(u, cfg)
+	}
+	return sources
+}
+
+// For returns the data source of a cluster.
+func (s *Sources) For(cluster string) (*Client, error) {
+	if s == nil {
+		return nil, ErrNotConfigured
+	}
+	if client, ok := s.clusters[cluster]; ok {
+		return client, nil
+	}
+	if s.fallback != nil {
+		return s.fallback, nil
+	}
+	return nil, ErrNotConfigured
+}
@@ -0,0 +1,82 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kubezen/internal/config"
+)
+
+// newStandIn serves the query_range API the way Prometheus does.
+func newStandIn(t *testing.T, handler func(query string) (int, string)) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+		status, body := handler(r.Form.Get("query"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, config.PrometheusConfig{Timeout: 5 * time.Second})
+}
+
+func TestQueryRange(t *testing.T) {
+	client := newStandIn(t, func(query string) (int, string) {
+		if strings.Contains(query, "bad(") {
+			return http.StatusBadRequest, `{"status":"error","errorType":"bad_data","error":"parse error"}`
+		}
+		return http.StatusOK, `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"container":"app"},"values":[[1700000000,"0.25"],[1700000015.5,"NaN"],[1700000030,"0.5"]]}
+		]}}`
+	})
+
+	end := time.Unix(1700000030, 0)
+	series, err := client.QueryRange(context.Background(), "up", end.Add(-time.Minute), end, 15*time.Second)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(series) != 1 || series[0].Labels["container"] != "app" {
+		t.Fatalf("unexpected series %+v", series)
+	}
+	if points := series[0].Points; len(points) != 2 || points[1].V != 0.5 || !points[1].T.Equal(end) {
+		t.Fatalf("unexpected points %+v", points)
+	}
+
+	if _, err := client.QueryRange(context.Background(), "bad(", end.Add(-time.Minute), end, 15*time.Second); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Fatalf("expected prometheus error, got %v", err)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.yaml")
+	custom := "pod:\n  - name: cpu\n    title: CPU\n    unit: cores\n    query: 'my_cpu{ns=\"{{.Namespace}}\",pod=\"{{.Name}}\"}'\n"
+	if err := os.WriteFile(path, []byte(custom), 0600); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadTemplates(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(templates["pod"]) != len(defaultTemplates["pod"]) {
+		t.Fatalf("override should replace, not append")
+	}
+
+	query, err := templates["pod"][0].Render(Vars{Namespace: "team-a", Name: "web-1", Window: "2m"})
+	if err != nil || query != `my_cpu{ns="team-a",pod="web-1"}` {
+		t.Fatalf("unexpected render %q %v", query, err)
+	}
+	if _, err := templates["pod"][0].Render(Vars{Namespace: "x", Name: `web"} or vector(1) #`}); err == nil {
+		t.Fatalf("expected invalid name to be rejected")
+	}
+}
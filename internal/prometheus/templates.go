@@ -0,0 +1,140 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Template is a predefined PromQL query for one chart of a resource kind.
+// Queries use text/template with the fields of Vars.
+type Template struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Unit  string `json:"unit"`
+	Query string `json:"query"`
+}
+
+// Templates holds the chart templates per kind ("pod", "node", "deployment").
+type Templates map[string][]Template
+
+// Vars are substituted into templates. Namespace and Name are validated as
+// Kubernetes names so they can't break out of a label matcher.
+type Vars struct {
+	Namespace string
+	Name      string
+	Window    string // rate() window, e.g. "2m"
+}
+
+var defaultTemplates = Templates{
+	"pod": {
+		{Name: "cpu", Title: "CPU usage", Unit: "cores",
+			Query: `sum by (container) (rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}",pod="{{.Name}}",container!="",container!="POD"}[{{.Window}}]))`},
+		{Name: "memory", Title: "Memory working set", Unit: "bytes",
+			Query: `sum by (container) (container_memory_working_set_bytes{namespace="{{.Namespace}}",pod="{{.Name}}",container!="",container!="POD"})`},
+		{Name: "network_receive", Title: "Network received", Unit: "bytes/s",
+			Query: `sum(rate(container_network_receive_bytes_total{namespace="{{.Namespace}}",pod="{{.Name}}"}[{{.Window}}]))`},
+		{Name: "network_transmit", Title: "Network transmitted", Unit: "bytes/s",
+			Query: `sum(rate(container_network_transmit_bytes_total{namespace="{{.Namespace}}",pod="{{.Name}}"}[{{.Window}}]))`},
+	},
+	"node": {
+		{Name: "cpu", Title: "CPU usage", Unit: "cores",
+			Query: `sum(rate(container_cpu_usage_seconds_total{node="{{.Name}}",id="/"}[{{.Window}}]))`},
+		{Name: "memory", Title: "Memory working set", Unit: "bytes",
+			Query: `sum(container_memory_working_set_bytes{node="{{.Name}}",id="/"})`},
+		{Name: "pressure", Title: "Node pressure", Unit: "bool",
+			Query: `max by (condition) (kube_node_status_condition{node="{{.Name}}",condition=~"MemoryPressure|DiskPressure|PIDPressure",status="true"})`},
+	},
+	"deployment": {
+		{Name: "request_rate", Title: "Request rate", Unit: "req/s",
+			Query: `sum by (code) (rate(http_requests_total{namespace="{{.Namespace}}",pod=~"{{.Name}}-[a-z0-9]+-[a-z0-9]+"}[{{.Window}}]))`},
+		{Name: "cpu", Title: "CPU usage", Unit: "cores",
+			Query: `sum(rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}",pod=~"{{.Name}}-[a-z0-9]+-[a-z0-9]+",container!="",container!="POD"}[{{.Window}}]))`},
+		{Name: "available_replicas", Title: "Available replicas", Unit: "replicas",
+			Query: `kube_deployment_status_replicas_available{namespace="{{.Namespace}}",deployment="{{.Name}}"}`},
+	},
+}
+
+// LoadTemplates returns the built-in templates, overridden or extended by the
+// YAML file at path if set. A file entry replaces the built-in template of
+// the same kind and name.
+//
+//	pod:
+//	  - name: cpu
+//	    title: CPU usage
+//	    unit: cores
+//	    query: sum(rate(...{namespace="{{.Namespace}}",pod="{{.Name}}"}[{{.Window}}]))
+func LoadTemplates(path string) (Templates, error) {
+	templates := make(Templates, len(defaultTemplates))
+	for kind, list := range defaultTemplates {
+		templates[kind] = append([]Template(nil), list...)
+	}
+	if path == "" {
+		return templates, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read prometheus templates: %w", err)
+	}
+	var custom Templates
+	if err := yaml.Unmarshal(raw, &custom); err != nil {
+		return nil, fmt.Errorf("parse prometheus templates: %w", err)
+	}
+	for kind, list := range custom {
+		for _, tpl := range list {
+			if tpl.Name == "" || tpl.Query == "" {
+				return nil, fmt.Errorf("prometheus template for %s needs name and query", kind)
+			}
+			if _, err := template.New(tpl.Name).Parse(tpl.Query); err != nil {
+				return nil, fmt.Errorf("prometheus template %s/%s: %w", kind, tpl.Name, err)
+			}
+			templates[kind] = upsertTemplate(templates[kind], tpl)
+		}
+	}
+	return templates, nil
+}
+
+func upsertTemplate(list []Template, tpl Template) []Template {
+	for i := range list {
+		if list[i].Name == tpl.Name {
+			list[i] = tpl
+			return list
+		}
+	}
+	return append(list, tpl)
+}
+
+var kubeName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+// Render fills in the template's query.
+func (t Template) Render(vars Vars) (string, error) {
+	for _, v := range []string{vars.Namespace, vars.Name} {
+		if v != "" && (len(v) > 253 || !kubeName.MatchString(v)) {
+			return "", fmt.Errorf("invalid name %q", v)
+		}
+	}
+	tpl, err := template.New(t.Name).Option("missingkey=error").Parse(t.Query)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RateWindow picks a rate() window covering at least a few scrapes per step.
+func RateWindow(step time.Duration) string {
+	window := 4 * step
+	if window < 2*time.Minute {
+		window = 2 * time.Minute
+	}
+	return fmt.Sprintf("%ds", int64(window/time.Second))
+}
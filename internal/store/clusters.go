@@ -0,0 +1,200 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// encryptedPrefix marks kubeconfigs encrypted with the store's key.
+const encryptedPrefix = "enc:v1:"
+
+// ClusterRecord is a cluster registered through the API. The kubeconfig holds
+// credentials; it is encrypted at rest and never serialized.
+type ClusterRecord struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Kubeconfig string    `json:"-"`
+	Context    string    `json:"context,omitempty"`
+	CreatedBy  string    `json:"createdBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// KubeconfigErr is set by ListClusters when the stored kubeconfig can't
+	// be decrypted, e.g. after the encryption key changed.
+	KubeconfigErr error `json:"-"`
+}
+
+var (
+	ErrClusterNotFound      = errors.New("cluster not found")
+	ErrClusterAlreadyExists = errors.New("cluster already exists")
+	ErrNoEncryptionKey      = errors.New("no encryption key configured for cluster credentials")
+)
+
+// SetEncryptionKey derives the key that encrypts stored kubeconfigs from
+// secret, and encrypts registrations still stored in plaintext.
+func (s *Store) SetEncryptionKey(secret string) error {
+	if secret == "" {
+		return ErrNoEncryptionKey
+	}
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, "kubezen cluster credentials", 32)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if s.clusterKey, err = cipher.NewGCM(block); err != nil {
+		return err
+	}
+
+	rows, err := s.db.Query("SELECT id, kubeconfig FROM clusters WHERE kubeconfig NOT LIKE ?", encryptedPrefix+"%")
+	if err != nil {
+		return err
+	}
+	plain := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var kubeconfig string
+		if err := rows.Scan(&id, &kubeconfig); err != nil {
+			rows.Close()
+			return err
+		}
+		plain[id] = kubeconfig
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, kubeconfig := range plain {
+		sealed, err := s.sealKubeconfig(kubeconfig)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.Exec("UPDATE clusters SET kubeconfig = ? WHERE id = ?", sealed, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanStoreClusters reports whether an encryption key is set, which
+// CreateCluster requires.
+func (s *Store) CanStoreClusters() bool {
+	return s.clusterKey != nil
+}
+
+func (s *Store) sealKubeconfig(kubeconfig string) (string, error) {
+	if s.clusterKey == nil {
+		return "", ErrNoEncryptionKey
+	}
+	nonce := make([]byte, s.clusterKey.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.clusterKey.Seal(nonce, nonce, []byte(kubeconfig), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Store) openKubeconfig(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedPrefix)
+	if !ok {
+		return "", errors.New("cluster kubeconfig is not encrypted")
+	}
+	if s.clusterKey == nil {
+		return "", ErrNoEncryptionKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.clusterKey.NonceSize() {
+		return "", errors.New("malformed encrypted kubeconfig")
+	}
+	nonce, ciphertext := sealed[:s.clusterKey.NonceSize()], sealed[s.clusterKey.NonceSize():]
+	plain, err := s.clusterKey.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt kubeconfig: %w", err)
+	}
+	return string(plain), nil
+}
+
+// CreateCluster stores a cluster registration, encrypting its kubeconfig.
+func (s *Store) CreateCluster(name, kubeconfig, contextName, createdBy string) (*ClusterRecord, error) {
+	sealed, err := s.sealKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.db.Exec(
+		"INSERT INTO clusters (name, kubeconfig, context, created_by) VALUES (?, ?, ?, ?)",
+		name, sealed, contextName, createdBy,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrClusterAlreadyExists
+		}
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ClusterRecord{}
+	err = s.db.QueryRow(
+		"SELECT id, name, kubeconfig, context, created_by, created_at FROM clusters WHERE id = ?",
+		id,
+	).Scan(&record.ID, &record.Name, &record.Kubeconfig, &record.Context, &record.CreatedBy, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrClusterNotFound
+		}
+		return nil, err
+	}
+	record.Kubeconfig = kubeconfig
+	return record, nil
+}
+
+// ListClusters returns all registered clusters with decrypted kubeconfigs.
+// Rows that fail to decrypt are returned with KubeconfigErr set instead of
+// failing the whole list.
+func (s *Store) ListClusters() ([]ClusterRecord, error) {
+	rows, err := s.db.Query("SELECT id, name, kubeconfig, context, created_by, created_at FROM clusters ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clusters []ClusterRecord
+	for rows.Next() {
+		var record ClusterRecord
+		if err := rows.Scan(&record.ID, &record.Name, &record.Kubeconfig, &record.Context, &record.CreatedBy, &record.CreatedAt); err != nil {
+			return nil, err
+		}
+		if record.Kubeconfig, err = s.openKubeconfig(record.Kubeconfig); err != nil {
+			record.KubeconfigErr = err
+		}
+		clusters = append(clusters, record)
+	}
+	return clusters, rows.Err()
+}
+
+// DeleteCluster removes a cluster registration by name.
+func (s *Store) DeleteCluster(name string) error {
+	result, err := s.db.Exec("DELETE FROM clusters WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrClusterNotFound
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterKubeconfigEncryptedAtRest(t *testing.T) {
+	s := newTestStore(t)
+	if _, err := s.CreateCluster("prod", "token: secret", "prod", "admin"); err != ErrNoEncryptionKey {
+		t.Fatalf("expected registrations to need a key, got %v", err)
+	}
+	// A plaintext row from before encryption is sealed once the key is set.
+	if _, err := s.db.Exec("INSERT INTO clusters (name, kubeconfig) VALUES ('legacy', 'token: legacy')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := s.SetEncryptionKey("store-secret"); err != nil {
+		t.Fatalf("set key: %v", err)
+	}
+	if _, err := s.CreateCluster("prod", "token: secret", "prod", "admin"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	rows, err := s.db.Query("SELECT kubeconfig FROM clusters")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stored string
+		if err := rows.Scan(&stored); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if !strings.HasPrefix(stored, encryptedPrefix) || strings.Contains(stored, "token") {
+			t.Fatalf("kubeconfig stored in plaintext: %q", stored)
+		}
+	}
+
+	clusters, err := s.ListClusters()
+	if err != nil || len(clusters) != 2 || clusters[0].Kubeconfig != "token: legacy" || clusters[1].Kubeconfig != "token: secret" {
+		t.Fatalf("unexpected clusters %+v %v", clusters, err)
+	}
+
+	if err := s.SetEncryptionKey("other-secret"); err != nil {
+		t.Fatalf("set key: %v", err)
+	}
+	// A wrong key only marks the rows, it doesn't fail the whole list.
+	clusters, err = s.ListClusters()
+	if err != nil || len(clusters) != 2 || clusters[0].KubeconfigErr == nil || clusters[0].Kubeconfig != "" {
+		t.Fatalf("expected undecryptable rows to be flagged, got %+v %v", clusters, err)
+	}
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Invite is a one-time onboarding link for a new local user. Only a hash of
+// the token is stored; the token itself is returned once on creation.
+type Invite struct {
+	ID         int64      `json:"id"`
+	Username   string     `json:"username,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Role       string     `json:"role"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+	RedeemedBy string     `json:"redeemedBy,omitempty"`
+}
+
+var (
+	ErrInviteNotFound = errors.New("invite not found")
+	ErrInviteInvalid  = errors.New("invite expired or already used")
+)
+
+const inviteColumns = "id, username, email, role, created_by, created_at, expires_at, redeemed_at, redeemed_by"
+
+// CreateInvite stores a new invite and returns it together with its token.
+func (s *Store) CreateInvite(username, email, role, createdBy string, ttl time.Duration) (*Invite, string, error) {
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	result, err := s.db.Exec(
+		"INSERT INTO invites (token_hash, username, email, role, created_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		hashInviteToken(token), username, email, role, createdBy, now, now.Add(ttl),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, "", err
+	}
+	invite, err := s.getInvite("SELECT "+inviteColumns+" FROM invites WHERE id = ?", id)
+	if err != nil {
+		return nil, "", err
+	}
+	return invite, token, nil
+}
+
+// ListInvites returns all invites, newest first.
+func (s *Store) ListInvites() ([]Invite, error) {
+	rows, err := s.db.Query("SELECT " + inviteColumns + " FROM invites ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []Invite
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *invite)
+	}
+	return invites, rows.Err()
+}
+
+// GetInviteByToken returns a pending invite for the given token.
+func (s *Store) GetInviteByToken(token string) (*Invite, error) {
+	invite, err := s.getInvite("SELECT "+inviteColumns+" FROM invites WHERE token_hash = ?", hashInviteToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !invite.pending(time.Now()) {
+		return nil, ErrInviteInvalid
+	}
+	return invite, nil
+}
+
+// DeleteInvite revokes an invite by ID.
+func (s *Store) DeleteInvite(id int64) error {
+	result, err := s.db.Exec("DELETE FROM invites WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// RedeemInvite creates the invited user and marks the invite as used in a
+// single transaction so a token can only ever be redeemed once.
+func (s *Store) RedeemInvite(token, username, password string) (*User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invite, err := scanInvite(tx.QueryRow("SELECT "+inviteColumns+" FROM invites WHERE token_hash = ?", hashInviteToken(token)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !invite.pending(now) {
+		return nil, ErrInviteInvalid
+	}
+	if invite.Username != "" {
+		username = invite.Username
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)",
+		username, string(hash), invite.Role,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+	userID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = tx.Exec(
+		"UPDATE invites SET redeemed_at = ?, redeemed_by = ? WHERE id = ? AND redeemed_at IS NULL",
+		now, username, invite.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, ErrInviteInvalid
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(userID)
+}
+
+func (s *Store) getInvite(query string, args ...any) (*Invite, error) {
+	invite, err := scanInvite(s.db.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, err
+	}
+	return invite, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvite(row rowScanner) (*Invite, error) {
+	invite := &Invite{}
+	var redeemedAt sql.NullTime
+	var redeemedBy sql.NullString
+	if err := row.Scan(&invite.ID, &invite.Username, &invite.Email, &invite.Role, &invite.CreatedBy,
+		&invite.CreatedAt, &invite.ExpiresAt, &redeemedAt, &redeemedBy); err != nil {
+		return nil, err
+	}
+	if redeemedAt.Valid {
+		invite.RedeemedAt = &redeemedAt.Time
+	}
+	invite.RedeemedBy = redeemedBy.String
+	return invite, nil
+}
+
+func (i *Invite) pending(now time.Time) bool {
+	return i.RedeemedAt == nil && now.Before(i.ExpiresAt)
+}
+
+func newInviteToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteRedeemIsSingleUse(t *testing.T) {
+	s := newTestStore(t)
+
+	invite, token, err := s.CreateInvite("", "bob@example.com", "user", "admin", time.Hour)
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	if token == "" || invite.Email != "bob@example.com" {
+		t.Fatalf("unexpected invite: %+v", invite)
+	}
+	if _, err := s.GetInviteByToken(token); err != nil {
+		t.Fatalf("expected pending invite, got %v", err)
+	}
+
+	user, err := s.RedeemInvite(token, "bob", "bob-password")
+	if err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if user.Username != "bob" || user.Role != "user" || !s.VerifyPassword(user, "bob-password") {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	if _, err := s.RedeemInvite(token, "mallory", "other-password"); err != ErrInviteInvalid {
+		t.Fatalf("expected second redemption to fail, got %v", err)
+	}
+	if _, err := s.RedeemInvite("bogus", "mallory", "other-password"); err != ErrInviteNotFound {
+		t.Fatalf("expected unknown token to fail, got %v", err)
+	}
+
+	invites, err := s.ListInvites()
+	if err != nil || len(invites) != 1 || invites[0].RedeemedAt == nil || invites[0].RedeemedBy != "bob" {
+		t.Fatalf("unexpected invites: %+v %v", invites, err)
+	}
+}
+
+func TestExpiredInviteIsRejected(t *testing.T) {
+	s := newTestStore(t)
+	_, token, err := s.CreateInvite("carol", "", "admin", "", -time.Minute)
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	if _, err := s.GetInviteByToken(token); err != ErrInviteInvalid {
+		t.Fatalf("expected expired invite, got %v", err)
+	}
+	if _, err := s.RedeemInvite(token, "", "carol-password"); err != ErrInviteInvalid {
+		t.Fatalf("expected expired invite to be rejected, got %v", err)
+	}
+}
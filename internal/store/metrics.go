@@ -0,0 +1,107 @@
+package store
+
+import (
+	"time"
+)
+
+// MetricSample is one usage data point. Resolution is zero for raw samples
+// and the bucket size for downsampled ones.
+type MetricSample struct {
+	Cluster       string        `json:"-"`
+	Kind          string        `json:"-"`
+	Namespace     string        `json:"-"`
+	Name          string        `json:"-"`
+	Resolution    time.Duration `json:"-"`
+	Timestamp     time.Time     `json:"t"`
+	CPUMillicores int64         `json:"cpu"`
+	MemoryBytes   int64         `json:"memory"`
+}
+
+// InsertMetricSamples stores raw samples in a single transaction.
+func (s *Store) InsertMetricSamples(samples []MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO metric_samples
+		(cluster, kind, namespace, name, resolution, ts, cpu_millicores, memory_bytes)
+		VALUES (?, ?, ?, ?, 0, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, sample := range samples {
+		if _, err := stmt.Exec(sample.Cluster, sample.Kind, sample.Namespace, sample.Name,
+			sample.Timestamp.Unix(), sample.CPUMillicores, sample.MemoryBytes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CompactMetricSamples averages raw samples older than rawRetention into
+// rollup buckets and deletes anything older than retention, so the table
+// works as a fixed-size ring per series.
+func (s *Store) CompactMetricSamples(now time.Time, rollup, rawRetention, retention time.Duration) error {
+	step := int64(rollup / time.Second)
+	if step <= 0 {
+		step = 1
+	}
+	// Only compact whole buckets so a bucket is never written twice.
+	cutoff := now.Add(-rawRetention).Unix() / step * step
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO metric_samples
+		(cluster, kind, namespace, name, resolution, ts, cpu_millicores, memory_bytes)
+		SELECT cluster, kind, namespace, name, ?, (ts / ?) * ?, CAST(AVG(cpu_millicores) AS INTEGER), CAST(AVG(memory_bytes) AS INTEGER)
+		FROM metric_samples
+		WHERE resolution = 0 AND ts < ?
+		GROUP BY cluster, kind, namespace, name, ts / ?`,
+		step, step, step, cutoff, step); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM metric_samples WHERE resolution = 0 AND ts < ?", cutoff); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM metric_samples WHERE ts < ?", now.Add(-retention).Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MetricHistory returns the samples of one series since the given time,
+// oldest first. Older points come from rollups, recent ones are raw.
+func (s *Store) MetricHistory(cluster, kind, namespace, name string, since time.Time) ([]MetricSample, error) {
+	rows, err := s.db.Query(`SELECT resolution, ts, cpu_millicores, memory_bytes
+		FROM metric_samples
+		WHERE cluster = ? AND kind = ? AND namespace = ? AND name = ? AND ts >= ?
+		ORDER BY ts`,
+		cluster, kind, namespace, name, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]MetricSample, 0)
+	for rows.Next() {
+		sample := MetricSample{Cluster: cluster, Kind: kind, Namespace: namespace, Name: name}
+		var resolution, ts int64
+		if err := rows.Scan(&resolution, &ts, &sample.CPUMillicores, &sample.MemoryBytes); err != nil {
+			return nil, err
+		}
+		sample.Resolution = time.Duration(resolution) * time.Second
+		sample.Timestamp = time.Unix(ts, 0).UTC()
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
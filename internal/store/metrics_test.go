@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompactMetricSamples(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sample := func(age time.Duration, cpu int64) MetricSample {
+		return MetricSample{Cluster: "dev", Kind: "pod", Namespace: "default", Name: "web",
+			Timestamp: now.Add(-age), CPUMillicores: cpu, MemoryBytes: cpu * 10}
+	}
+	if err := s.InsertMetricSamples([]MetricSample{
+		sample(50*time.Hour, 999),            // past retention
+		sample(3*time.Hour, 100),             // same 5m bucket ...
+		sample(3*time.Hour-time.Minute, 300), // ... averaged together
+		sample(10*time.Minute, 50),           // still raw
+	}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := s.CompactMetricSamples(now, 5*time.Minute, 2*time.Hour, 48*time.Hour); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	history, err := s.MetricHistory("dev", "pod", "default", "web", now.Add(-72*time.Hour))
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected rollup and raw point, got %+v", history)
+	}
+	if history[0].Resolution != 5*time.Minute || history[0].CPUMillicores != 200 {
+		t.Fatalf("unexpected rollup %+v", history[0])
+	}
+	if history[1].Resolution != 0 || history[1].CPUMillicores != 50 {
+		t.Fatalf("unexpected raw point %+v", history[1])
+	}
+}
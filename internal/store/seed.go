@@ -0,0 +1,182 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"sigs.k8s.io/yaml"
+
+	"kubezen/internal/config"
+)
+
+// SeedUser declares a local user provisioned from configuration. Exactly one
+// of PasswordHash or PasswordFile must be set; the file may hold either a
+// plain password or a bcrypt hash (e.g. a mounted Kubernetes Secret).
+type SeedUser struct {
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	PasswordHash string `json:"passwordHash,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+}
+
+// SeedResult lists the usernames touched by Seed.
+type SeedResult struct {
+	Created []string
+	Updated []string
+	Deleted []string
+}
+
+type seedFile struct {
+	Users []SeedUser `json:"users"`
+}
+
+var ErrEmptySeed = errors.New("refusing to reconcile an empty user list")
+
+// BootstrapUsers collects the users declared through the bootstrap admin
+// settings and the users file.
+func BootstrapUsers(cfg config.BootstrapConfig) ([]SeedUser, error) {
+	var users []SeedUser
+	if cfg.AdminUsername != "" {
+		users = append(users, SeedUser{
+			Username:     cfg.AdminUsername,
+			Role:         "admin",
+			PasswordHash: cfg.AdminPasswordHash,
+			PasswordFile: cfg.AdminPasswordFile,
+		})
+	}
+	if cfg.UsersFile != "" {
+		raw, err := os.ReadFile(cfg.UsersFile)
+		if err != nil {
+			return nil, fmt.Errorf("read users file: %w", err)
+		}
+		var file seedFile
+		if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+			return nil, fmt.Errorf("parse users file: %w", err)
+		}
+		users = append(users, file.Users...)
+	}
+	return users, nil
+}
+
+// Seed creates the declared users that don't exist yet. With reconcile set it
+// also updates the password and role of existing users and deletes users that
+// are not declared.
+func (s *Store) Seed(users []SeedUser, reconcile bool) (SeedResult, error) {
+	var result SeedResult
+	if reconcile && len(users) == 0 {
+		return result, ErrEmptySeed
+	}
+
+	declared := make(map[string]bool, len(users))
+	for _, seed := range users {
+		seed.Username = strings.TrimSpace(seed.Username)
+		if seed.Username == "" {
+			return result, fmt.Errorf("seed user without username")
+		}
+		if declared[seed.Username] {
+			return result, fmt.Errorf("seed user %q declared twice", seed.Username)
+		}
+		declared[seed.Username] = true
+		if seed.Role == "" {
+			seed.Role = "user"
+		}
+
+		hash, plain, err := seed.password()
+		if err != nil {
+			return result, fmt.Errorf("seed user %q: %w", seed.Username, err)
+		}
+
+		existing, err := s.GetUserByUsername(seed.Username)
+		if errors.Is(err, ErrUserNotFound) {
+			if hash == "" {
+				generated, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+				if err != nil {
+					return result, err
+				}
+				hash = string(generated)
+			}
+			if _, err := s.CreateUserWithHash(seed.Username, hash, seed.Role); err != nil {
+				return result, fmt.Errorf("create seed user %q: %w", seed.Username, err)
+			}
+			result.Created = append(result.Created, seed.Username)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if !reconcile {
+			continue
+		}
+
+		// Keep the stored hash when the declared password still matches so
+		// plain-text secrets aren't re-hashed on every boot.
+		switch {
+		case hash != "" && hash != existing.PasswordHash:
+		case hash == "" && !s.VerifyPassword(existing, plain):
+			generated, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+			if err != nil {
+				return result, err
+			}
+			hash = string(generated)
+		default:
+			if existing.Role == seed.Role {
+				continue
+			}
+			hash = existing.PasswordHash
+		}
+		if err := s.UpdateUserCredentials(existing.ID, hash, seed.Role); err != nil {
+			return result, fmt.Errorf("update seed user %q: %w", seed.Username, err)
+		}
+		result.Updated = append(result.Updated, seed.Username)
+	}
+
+	if !reconcile {
+		return result, nil
+	}
+	all, err := s.ListUsers()
+	if err != nil {
+		return result, err
+	}
+	for _, user := range all {
+		if declared[user.Username] {
+			continue
+		}
+		if err := s.DeleteUser(user.ID); err != nil {
+			return result, fmt.Errorf("delete undeclared user %q: %w", user.Username, err)
+		}
+		result.Deleted = append(result.Deleted, user.Username)
+	}
+	return result, nil
+}
+
+// password resolves the declared credential into either a bcrypt hash or a
+// plain-text password.
+func (u SeedUser) password() (hash, plain string, err error) {
+	switch {
+	case u.PasswordHash != "" && u.PasswordFile != "":
+		return "", "", fmt.Errorf("set only one of passwordHash and passwordFile")
+	case u.PasswordHash != "":
+		if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+			return "", "", fmt.Errorf("invalid bcrypt hash: %w", err)
+		}
+		return u.PasswordHash, "", nil
+	case u.PasswordFile != "":
+		raw, err := os.ReadFile(u.PasswordFile)
+		if err != nil {
+			return "", "", fmt.Errorf("read password file: %w", err)
+		}
+		value := strings.TrimSpace(string(raw))
+		if value == "" {
+			return "", "", fmt.Errorf("password file %s is empty", u.PasswordFile)
+		}
+		if _, err := bcrypt.Cost([]byte(value)); err == nil {
+			return value, "", nil
+		}
+		return "", value, nil
+	default:
+		return "", "", fmt.Errorf("no password configured")
+	}
+}
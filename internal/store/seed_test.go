@@ -0,0 +1,68 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(filepath.Join(t.TempDir(), "kubezen.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSeedCreatesAndReconcilesUsers(t *testing.T) {
+	s := newTestStore(t)
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte("first-password\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser("stale", "secret1", "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	seeds := []SeedUser{{Username: "admin", Role: "admin", PasswordFile: secret}}
+
+	result, err := s.Seed(seeds, false)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Deleted) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	admin, err := s.GetUserByUsername("admin")
+	if err != nil || admin.Role != "admin" || !s.VerifyPassword(admin, "first-password") {
+		t.Fatalf("admin not seeded correctly: %+v %v", admin, err)
+	}
+
+	// Unchanged secrets are a no-op on the next boot.
+	result, err = s.Seed(seeds, true)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(result.Updated) != 0 || len(result.Deleted) != 1 || result.Deleted[0] != "stale" {
+		t.Fatalf("unexpected reconcile result: %+v", result)
+	}
+
+	// A rotated secret updates the stored password.
+	if err := os.WriteFile(secret, []byte("rotated-password"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err = s.Seed(seeds, true)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	admin, _ = s.GetUserByUsername("admin")
+	if len(result.Updated) != 1 || !s.VerifyPassword(admin, "rotated-password") {
+		t.Fatalf("expected rotated password, got %+v", result)
+	}
+
+	if _, err := s.Seed(nil, true); err != ErrEmptySeed {
+		t.Fatalf("expected empty reconcile to be refused, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"context"
+	"crypto/cipher"
 	"database/sql"
 	"os"
 	"path/filepath"
@@ -10,7 +12,8 @@ import (
 
 // Store provides access to the SQLite database.
 type Store struct {
-	db *sql.DB
+	db         *sql.DB
+	clusterKey cipher.AEAD // encrypts cluster kubeconfigs, see SetEncryptionKey
 }
 
 // New creates a new Store with the given database path.
@@ -47,6 +50,12 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
+// Ping checks that the database answers queries.
+func (s *Store) Ping(ctx context.Context) error {
+	var one int
+	return s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
 // migrate runs database migrations.
 func (s *Store) migrate() error {
 	schema := `
@@ -59,6 +68,42 @@ func (s *Store) migrate() error {
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+
+	CREATE TABLE IF NOT EXISTS invites (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token_hash TEXT UNIQUE NOT NULL,
+		username TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL DEFAULT 'user',
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		redeemed_at DATETIME,
+		redeemed_by TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS clusters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		kubeconfig TEXT NOT NULL,
+		context TEXT NOT NULL DEFAULT '',
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS metric_samples (
+		cluster TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		namespace TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		resolution INTEGER NOT NULL,
+		ts INTEGER NOT NULL,
+		cpu_millicores INTEGER NOT NULL,
+		memory_bytes INTEGER NOT NULL,
+		PRIMARY KEY (cluster, kind, namespace, name, resolution, ts)
+	) WITHOUT ROWID;
+
+	CREATE INDEX IF NOT EXISTS idx_metric_samples_age ON metric_samples(resolution, ts);
 	`
 	_, err := s.db.Exec(schema)
 	return err
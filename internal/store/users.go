@@ -50,6 +50,27 @@ func (s *Store) CreateUser(username, password, role string) (*User, error) {
 	return s.GetUserByID(id)
 }
 
+// CreateUserWithHash creates a user from an existing bcrypt hash.
+func (s *Store) CreateUserWithHash(username, passwordHash, role string) (*User, error) {
+	result, err := s.db.Exec(
+		"INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)",
+		username, passwordHash, role,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(id)
+}
+
 // GetUserByID returns a user by ID.
 func (s *Store) GetUserByID(id int64) (*User, error) {
 	user := &User{}
@@ -117,6 +138,42 @@ func (s *Store) DeleteUser(id int64) error {
 	return nil
 }
 
+// UpdatePassword replaces the password of the user with the given ID.
+func (s *Store) UpdatePassword(id int64, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec("UPDATE users SET password_hash = ? WHERE id = ?", string(hash), id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateUserCredentials replaces the password hash and role of a user.
+func (s *Store) UpdateUserCredentials(id int64, passwordHash, role string) error {
+	result, err := s.db.Exec("UPDATE users SET password_hash = ?, role = ? WHERE id = ?", passwordHash, role, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 // CountUsers returns the total number of users.
 func (s *Store) CountUsers() (int, error) {
 	var count int
@@ -0,0 +1,59 @@
+package telemetry
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+)
+
+var (
+	kubeRequestLatency = promclient.NewHistogramVec(promclient.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kube_client_request_duration_seconds",
+		Help:      "Kubernetes API request latency by verb and host.",
+		Buckets:   []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"verb", "host"})
+
+	kubeRateLimiterLatency = promclient.NewHistogramVec(promclient.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kube_client_rate_limiter_duration_seconds",
+		Help:      "Time Kubernetes API requests spent waiting on the client-side rate limiter.",
+		Buckets:   []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"verb", "host"})
+
+	kubeRequests = promclient.NewCounterVec(promclient.CounterOpts{
+		Namespace: namespace,
+		Name:      "kube_client_requests_total",
+		Help:      "Kubernetes API requests by status code, method and host.",
+	}, []string{"code", "method", "host"})
+)
+
+func init() {
+	Registry.MustRegister(kubeRequestLatency, kubeRateLimiterLatency, kubeRequests)
+	clientmetrics.Register(clientmetrics.RegisterOpts{
+		RequestLatency:     latencyAdapter{kubeRequestLatency},
+		RateLimiterLatency: latencyAdapter{kubeRateLimiterLatency},
+		RequestResult:      resultAdapter{kubeRequests},
+	})
+}
+
+// latencyAdapter drops the request path; labelling by URL would create a
+// series per object.
+type latencyAdapter struct {
+	metric *promclient.HistogramVec
+}
+
+func (a latencyAdapter) Observe(_ context.Context, verb string, u url.URL, latency time.Duration) {
+	a.metric.WithLabelValues(verb, u.Host).Observe(latency.Seconds())
+}
+
+type resultAdapter struct {
+	metric *promclient.CounterVec
+}
+
+func (a resultAdapter) Increment(_ context.Context, code, method, host string) {
+	a.metric.WithLabelValues(code, method, host).Inc()
+}
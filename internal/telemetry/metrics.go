@@ -0,0 +1,90 @@
+// Package telemetry exposes KubeZen's own Prometheus metrics.
+package telemetry
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kubezen"
+
+// Registry holds every KubeZen metric. It's separate from the global default
+// registry so libraries can't add metrics behind our back.
+var Registry = promclient.NewRegistry()
+
+var (
+	httpRequests = promclient.NewCounterVec(promclient.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promclient.NewHistogramVec(promclient.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route. Streaming endpoints are excluded.",
+		Buckets:   promclient.DefBuckets,
+	}, []string{"method", "route"})
+
+	logins = promclient.NewCounterVec(promclient.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by method and result.",
+	}, []string{"method", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		logins,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format. A
+// non-empty token is required as "Authorization: Bearer <token>".
+func Handler(token string) http.Handler {
+	metrics := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return metrics
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
+
+// ObserveRequest records a finished HTTP request. route is the matched route
+// pattern, not the raw path, to keep label cardinality bounded. A zero
+// duration skips the latency histogram.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	if duration > 0 {
+		httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+	}
+}
+
+// RecordLogin counts a login attempt for method, e.g. "local" or "oidc".
+func RecordLogin(method string, success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	logins.WithLabelValues(method, result).Inc()
+}
@@ -0,0 +1,119 @@
+package telemetry
+
+import (
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+
+	"kubezen/internal/auth"
+	"kubezen/internal/k8s"
+)
+
+var (
+	activeSessionsDesc = promclient.NewDesc(
+		namespace+"_active_sessions",
+		"Unexpired sessions by login source.",
+		[]string{"source"}, nil,
+	)
+	informerObjectsDesc = promclient.NewDesc(
+		namespace+"_informer_objects",
+		"Objects held in each informer cache.",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerSyncedDesc = promclient.NewDesc(
+		namespace+"_informer_synced",
+		"Whether the informer completed its initial list (1) or not (0).",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerLastEventDesc = promclient.NewDesc(
+		namespace+"_informer_last_event_age_seconds",
+		"Seconds since the informer last received a change from the API server.",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerLastSyncDesc = promclient.NewDesc(
+		namespace+"_informer_last_sync_age_seconds",
+		"Seconds since the informer last listed its resource, on start or after its watch expired.",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerCacheAgeDesc = promclient.NewDesc(
+		namespace+"_informer_cache_age_seconds",
+		"Seconds since the informer last received a change or renewed its watch.",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerWatchErrorsDesc = promclient.NewDesc(
+		namespace+"_informer_watch_errors_total",
+		"Failed list or watch calls of the informer.",
+		[]string{"cluster", "resource"}, nil,
+	)
+	informerRestartsDesc = promclient.NewDesc(
+		namespace+"_informer_restarts_total",
+		"Times the watchdog restarted a cluster's informers because of a stale cache.",
+		[]string{"cluster"}, nil,
+	)
+)
+
+// stateCollector reads sessions and informer caches at scrape time instead of
+// keeping gauges up to date on every change.
+type stateCollector struct {
+	registry *k8s.Registry
+	manager  *auth.Manager
+}
+
+// RegisterState adds session and informer metrics read from registry and
+// manager. Call it once at startup.
+func RegisterState(registry *k8s.Registry, manager *auth.Manager) {
+	Registry.MustRegister(&stateCollector{registry: registry, manager: manager})
+}
+
+func (s *stateCollector) Describe(ch chan<- *promclient.Desc) {
+	ch <- activeSessionsDesc
+	ch <- informerObjectsDesc
+	ch <- informerSyncedDesc
+	ch <- informerLastEventDesc
+	ch <- informerLastSyncDesc
+	ch <- informerCacheAgeDesc
+	ch <- informerWatchErrorsDesc
+	ch <- informerRestartsDesc
+}
+
+func (s *stateCollector) Collect(ch chan<- promclient.Metric) {
+	now := time.Now()
+
+	sessions := map[auth.SessionSource]int{
+		auth.SourceLocal:      0,
+		auth.SourceOIDC:       0,
+		auth.SourceLDAP:       0,
+		auth.SourceKubeconfig: 0,
+	}
+	for _, session := range s.manager.ListSessions() {
+		if session.ExpiresAt.IsZero() || now.Before(session.ExpiresAt) {
+			sessions[session.Source]++
+		}
+	}
+	for source, count := range sessions {
+		ch <- promclient.MustNewConstMetric(activeSessionsDesc, promclient.GaugeValue, float64(count), string(source))
+	}
+
+	for _, stat := range s.registry.InformerStats() {
+		ch <- promclient.MustNewConstMetric(informerObjectsDesc, promclient.GaugeValue, float64(stat.Objects), stat.Cluster, stat.Resource)
+		synced := 0.0
+		if stat.Synced {
+			synced = 1
+		}
+		ch <- promclient.MustNewConstMetric(informerSyncedDesc, promclient.GaugeValue, synced, stat.Cluster, stat.Resource)
+		if !stat.LastEvent.IsZero() {
+			ch <- promclient.MustNewConstMetric(informerLastEventDesc, promclient.GaugeValue, now.Sub(stat.LastEvent).Seconds(), stat.Cluster, stat.Resource)
+		}
+		if !stat.LastSync.IsZero() {
+			ch <- promclient.MustNewConstMetric(informerLastSyncDesc, promclient.GaugeValue, now.Sub(stat.LastSync).Seconds(), stat.Cluster, stat.Resource)
+		}
+		ch <- promclient.MustNewConstMetric(informerCacheAgeDesc, promclient.GaugeValue, stat.CacheAge.Seconds(), stat.Cluster, stat.Resource)
+		ch <- promclient.MustNewConstMetric(informerWatchErrorsDesc, promclient.CounterValue, float64(stat.WatchErrors), stat.Cluster, stat.Resource)
+	}
+
+	for _, cluster := range s.registry.List() {
+		if cluster.Started {
+			ch <- promclient.MustNewConstMetric(informerRestartsDesc, promclient.CounterValue, float64(cluster.Restarts), cluster.Name)
+		}
+	}
+}